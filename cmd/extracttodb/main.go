@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"log/slog"
@@ -10,12 +11,28 @@ import (
 	"time"
 
 	"github.com/golang-cz/textcase"
+	"github.com/google/uuid"
 	"github.com/ivanvanderbyl/graphrag-go/pkg/extractors/entity"
 	"github.com/ivanvanderbyl/graphrag-go/pkg/llm"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/notify"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
+// Stages of a run reported to the webhook
+const (
+	stageExtraction    = notify.Stage(llm.StageExtraction)
+	stageCreateIndexes = notify.Stage("create_indexes")
+	stageWriteGraph    = notify.Stage("write_graph")
+)
+
+var (
+	modelsPath = flag.String("models", "", "Path to a JSON file assigning models to stages, e.g. {\"stages\": {\"extraction\": \"gpt-4o-mini\"}}")
+	webhookURL = flag.String("webhook", "", "URL to post run lifecycle events to, signed with $GRAPHRAG_WEBHOOK_SECRET when set")
+)
+
 func main() {
+	flag.Parse()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -24,12 +41,37 @@ func main() {
 	}
 }
 
-func realMain(ctx context.Context) error {
-	if len(os.Args) < 2 {
-		return fmt.Errorf("Usage: %s <document>", os.Args[0])
+func realMain(ctx context.Context) (err error) {
+	if flag.NArg() < 1 {
+		return fmt.Errorf("Usage: %s [-models <file>] [-webhook <url>] <document>", os.Args[0])
+	}
+
+	notifier := notify.Multi{}
+	if *webhookURL != "" {
+		notifier = append(notifier, notify.NewWebhookNotifier(*webhookURL, notify.WithSecret(os.Getenv("GRAPHRAG_WEBHOOK_SECRET"))))
+	}
+
+	// Failures are still reported when the run is cancelled or times out
+	notifyCtx := context.WithoutCancel(ctx)
+
+	run := notify.NewRun(notifier, uuid.NewString())
+	runStarted := time.Now()
+	warnNotify(run.Start(ctx))
+	defer func() {
+		if err != nil {
+			warnNotify(run.Fail(notifyCtx, err))
+		}
+	}()
+
+	models := llm.StageModels{}
+	if *modelsPath != "" {
+		models, err = llm.LoadStageModels(*modelsPath)
+		if err != nil {
+			return err
+		}
 	}
 
-	path := os.Args[1]
+	path := flag.Arg(0)
 	documentText, err := os.ReadFile(path)
 	if err != nil {
 		return err
@@ -67,12 +109,31 @@ func realMain(ctx context.Context) error {
 		"CREATE INDEX ON :Organization(embedding);",
 	}
 
-	slog.Info("Extracting entities from document")
 	openAILLM := llm.NewOpenAI(llm.WithCache(".cache"), llm.WithTemperature(0))
 
-	extractor := entity.NewEntityExtractor(openAILLM)
+	// stage runs fn as a stage of the run, notifying when it completes with the LLM usage of the
+	// stage, or fails
+	stage := func(name notify.Stage, fn func() error) error {
+		started, usage := time.Now(), currentUsage(openAILLM)
+		if err := fn(); err != nil {
+			warnNotify(run.StageFailed(notifyCtx, name, err))
+			return err
+		}
+		warnNotify(run.StageCompleted(ctx, name, &notify.Usage{
+			Usage:    currentUsage(openAILLM).Sub(usage),
+			Duration: time.Since(started),
+		}))
+		return nil
+	}
+
+	slog.Info("Extracting entities from document")
+	extractor := entity.NewEntityExtractor(openAILLM, entity.WithStageModels(models))
 
-	records, err := extractor.Extract(ctx, string(documentText))
+	var records []entity.Record
+	err = stage(stageExtraction, func() (err error) {
+		records, err = extractor.Extract(ctx, string(documentText))
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -82,14 +143,36 @@ func realMain(ctx context.Context) error {
 	defer session.Close(ctx)
 
 	slog.Info("Creating Indexes")
-	for _, index := range indexes {
-		_, err = session.Run(ctx, index, nil)
-		if err != nil {
-			return err
+	err = stage(stageCreateIndexes, func() error {
+		for _, index := range indexes {
+			if _, err := session.Run(ctx, index, nil); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	slog.Info("Creating Entities and Relationships")
+	err = stage(stageWriteGraph, func() error {
+		return writeRecords(ctx, session, records)
+	})
+	if err != nil {
+		return err
+	}
+
+	warnNotify(run.Complete(ctx, &notify.Usage{
+		Usage:    currentUsage(openAILLM),
+		Duration: time.Since(runStarted),
+	}))
+
+	return nil
+}
+
+// writeRecords merges extracted entities and relationships into the graph
+func writeRecords(ctx context.Context, session neo4j.SessionWithContext, records []entity.Record) error {
 	for _, record := range records {
 		switch r := record.(type) {
 		case *entity.Entity:
@@ -129,7 +212,7 @@ func realMain(ctx context.Context) error {
 
 			query := fmt.Sprintf("MATCH (from:$fromEntity {name: $from}), (to:$toEntity {name: $to}) MERGE (from)-[:%s {relation: $relation, weight: $weight}]->(to)", relationType)
 
-			_, err = session.Run(ctx, query, map[string]interface{}{
+			_, err := session.Run(ctx, query, map[string]interface{}{
 				"from":       r.Entity1,
 				"to":         r.Entity2,
 				"relation":   r.Relation,
@@ -146,6 +229,21 @@ func realMain(ctx context.Context) error {
 	return nil
 }
 
+// warnNotify logs notification errors, which shouldn't fail the run
+func warnNotify(err error) {
+	if err != nil {
+		slog.Warn("Failed to send notification", "error", err)
+	}
+}
+
+// currentUsage returns the usage of l so far, or zero usage when l doesn't track it
+func currentUsage(l llm.LLM) llm.Usage {
+	if reporter, ok := l.(llm.UsageReporter); ok {
+		return reporter.Usage()
+	}
+	return llm.Usage{}
+}
+
 func pascalCase(s string) string {
 	return textcase.PascalCase(s)
 }
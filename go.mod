@@ -1,13 +1,13 @@
 module github.com/ivanvanderbyl/graphrag-go
 
-go 1.22.0
+go 1.23.0
 
 require (
 	github.com/fraugster/parquet-go v0.12.0
 	github.com/google/uuid v1.3.0
 	github.com/pkg/errors v0.9.1
 	github.com/pkoukk/tiktoken-go v0.1.7
-	github.com/sashabaranov/go-openai v1.26.2
+	github.com/sashabaranov/go-openai v1.29.2
 	github.com/stretchr/testify v1.8.2
 )
 
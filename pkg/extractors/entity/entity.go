@@ -3,7 +3,6 @@ package entity
 import (
 	"context"
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -86,6 +85,28 @@ func (r *Relationship) String() string {
 	return buf.String()
 }
 
+// NewEntity returns an entity loaded from an existing index, rather than extracted from text
+func NewEntity(id, entityType, name, description string) *Entity {
+	return &Entity{
+		Name:         name,
+		Description:  description,
+		id:           id,
+		internalType: entityType,
+	}
+}
+
+// NewRelationship returns a relationship loaded from an existing index, rather than extracted
+// from text
+func NewRelationship(id, source, target, relation string, weight int) *Relationship {
+	return &Relationship{
+		Entity1:  source,
+		Relation: relation,
+		Entity2:  target,
+		Weight:   weight,
+		id:       id,
+	}
+}
+
 var DefaultEntityTypes = []string{"organization", "person", "policy", "bill", "geo", "event", "role", "electorate"}
 
 func NewEntityExtractor(llm llm.LLM, opts ...Option) *EntityExtractor {
@@ -105,6 +126,14 @@ func WithEntityTypes(types []string) Option {
 	}
 }
 
+// WithStageModels extracts entities with the extraction model, and embeds them with the
+// embedding model
+func WithStageModels(models llm.StageModels) Option {
+	return func(e *EntityExtractor) {
+		e.llm = models.ForStage(e.llm, llm.StageExtraction)
+	}
+}
+
 func (ee *EntityExtractor) Extract(ctx context.Context, text string) ([]Record, error) {
 	prompt, err := prompts.RenderTemplate(prompts.EntitiesTemplate, Data{
 		EntityTypes: ee.EntityTypes,
@@ -175,7 +204,7 @@ func parseRecord(record string) Record {
 	attrs := strings.Split(record, prompts.DefaultTupleDelimiter)
 
 	for i, v := range attrs {
-		str := cleanString(v)
+		str := prompts.CleanString(v)
 		attrs[i] = str
 	}
 
@@ -210,14 +239,3 @@ func parseRecord(record string) Record {
 		return nil
 	}
 }
-
-var re = regexp.MustCompile(`[\x00-\x1f\x7f-\x9f]`)
-
-func cleanString(str string) string {
-	str = strings.TrimSpace(str)
-	cleaned, err := strconv.Unquote(str)
-	if err != nil {
-		cleaned = str
-	}
-	return re.ReplaceAllString(cleaned, "")
-}
@@ -0,0 +1,335 @@
+package reports
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ivanvanderbyl/graphrag-go/pkg/llm"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/model"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/prompts"
+)
+
+// Number of reports rated together in a single prompt
+const DefaultBatchSize = 10
+
+// Number of already rated reports included in each prompt as a reference scale
+const DefaultAnchorCount = 3
+
+// Number of times reports missing from a response are sent to the LLM again
+const DefaultMaxRetries = 2
+
+type (
+	// RatingCalibrator re-scores community report ratings by comparing reports against each other
+	// in batches, rather than rating each report in isolation.
+	RatingCalibrator struct {
+		llm         llm.LLM
+		BatchSize   int
+		AnchorCount int
+		MaxRetries  int
+		MinRating   float64
+		MaxRating   float64
+	}
+
+	Option func(*RatingCalibrator)
+
+	// UnratedError is returned when the LLM fails to rate some reports, even after retrying.
+	UnratedError struct {
+		ReportIDs []string
+	}
+
+	Data struct {
+		prompts.PromptData
+		MinRating float64
+		MaxRating float64
+		Reports   []ReportItem
+		Anchors   []ReportItem
+	}
+
+	ReportItem struct {
+		ID      string
+		Title   string
+		Summary string
+		Rating  float64
+	}
+
+	Rating struct {
+		ReportID    string
+		Rating      float64
+		Explanation string
+	}
+
+	ratedReport struct {
+		report *model.CommunityReport
+		rating Rating
+	}
+)
+
+func (e *UnratedError) Error() string {
+	return fmt.Sprintf("failed to rate reports: %s", strings.Join(e.ReportIDs, ", "))
+}
+
+func NewRatingCalibrator(llm llm.LLM, opts ...Option) *RatingCalibrator {
+	rc := &RatingCalibrator{
+		llm:         llm,
+		BatchSize:   DefaultBatchSize,
+		AnchorCount: DefaultAnchorCount,
+		MaxRetries:  DefaultMaxRetries,
+		MinRating:   0,
+		MaxRating:   10,
+	}
+	for _, opt := range opts {
+		opt(rc)
+	}
+	return rc
+}
+
+// WithBatchSize sets the number of reports rated in each prompt
+func WithBatchSize(batchSize int) Option {
+	return func(rc *RatingCalibrator) {
+		rc.BatchSize = batchSize
+	}
+}
+
+// WithAnchorCount sets the number of reference reports included in each prompt
+func WithAnchorCount(anchorCount int) Option {
+	return func(rc *RatingCalibrator) {
+		rc.AnchorCount = anchorCount
+	}
+}
+
+// WithMaxRetries sets the number of times reports missing from a response are rated again
+func WithMaxRetries(maxRetries int) Option {
+	return func(rc *RatingCalibrator) {
+		rc.MaxRetries = maxRetries
+	}
+}
+
+// WithRatingScale sets the range of ratings
+func WithRatingScale(minRating, maxRating float64) Option {
+	return func(rc *RatingCalibrator) {
+		rc.MinRating = minRating
+		rc.MaxRating = maxRating
+	}
+}
+
+// WithStageModels rates reports with the reports model
+func WithStageModels(models llm.StageModels) Option {
+	return func(rc *RatingCalibrator) {
+		rc.llm = models.ForStage(rc.llm, llm.StageReports)
+	}
+}
+
+// Calibrate re-scores the Rank of each report in place. Reports are spread across batches so each
+// batch covers the full range of existing ratings, and every batch after the first is anchored
+// against reports that have already been re-scored. Reports missing from a response are rated
+// again, up to MaxRetries times.
+//
+// Ranks are only updated once every report has been rated, so reports are never left on a mix of
+// old and new scales. If a batch fails, or some reports can't be rated, no ranks are changed and
+// the unrated reports are returned in an *UnratedError.
+func (rc *RatingCalibrator) Calibrate(ctx context.Context, reports []*model.CommunityReport) error {
+	if len(reports) == 0 {
+		return nil
+	}
+
+	if rc.BatchSize <= 0 {
+		return fmt.Errorf("batch size must be greater than zero")
+	}
+
+	rated := make([]ratedReport, 0, len(reports))
+	unrated := make([]string, 0)
+
+	for _, batch := range rc.batches(reports) {
+		anchors := rc.selectAnchors(rated)
+
+		pending := batch
+		for attempt := 0; len(pending) > 0 && attempt <= rc.MaxRetries; attempt++ {
+			ratings, err := rc.rateBatch(ctx, pending, anchors)
+			if err != nil {
+				return err
+			}
+
+			missing := make([]*model.CommunityReport, 0)
+			for i, report := range pending {
+				rating, ok := ratings[strconv.Itoa(i+1)]
+				if !ok {
+					missing = append(missing, report)
+					continue
+				}
+
+				rating.Rating = rc.clamp(rating.Rating)
+				rated = append(rated, ratedReport{report: report, rating: rating})
+			}
+			pending = missing
+		}
+
+		for _, report := range pending {
+			unrated = append(unrated, report.ID)
+		}
+	}
+
+	if len(unrated) > 0 {
+		return &UnratedError{ReportIDs: unrated}
+	}
+
+	for _, r := range rated {
+		r.report.Rank = r.rating.Rating
+		r.report.RankExplanation = r.rating.Explanation
+	}
+
+	return nil
+}
+
+func (rc *RatingCalibrator) rateBatch(ctx context.Context, batch []*model.CommunityReport, anchors []ReportItem) (map[string]Rating, error) {
+	data := Data{
+		PromptData: prompts.DefaultPromptData,
+		MinRating:  rc.MinRating,
+		MaxRating:  rc.MaxRating,
+		Reports:    make([]ReportItem, 0, len(batch)),
+		Anchors:    anchors,
+	}
+
+	// Reports are referred to by their position in the batch, as LLMs are unreliable at
+	// reproducing long identifiers.
+	for i, report := range batch {
+		data.Reports = append(data.Reports, ReportItem{
+			ID:      strconv.Itoa(i + 1),
+			Title:   report.Title,
+			Summary: reportSummary(report),
+		})
+	}
+
+	prompt, err := prompts.RenderTemplate(prompts.ReportRatingsTemplate, data)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := rc.llm.Generate(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	return processResults(resp), nil
+}
+
+// batches sorts reports by their existing rank and deals them round-robin into batches, so that
+// each batch contains a mix of high and low rated reports.
+func (rc *RatingCalibrator) batches(reports []*model.CommunityReport) [][]*model.CommunityReport {
+	sorted := slices.Clone(reports)
+	slices.SortStableFunc(sorted, func(a, b *model.CommunityReport) int {
+		return cmp.Compare(b.Rank, a.Rank)
+	})
+
+	numBatches := (len(sorted) + rc.BatchSize - 1) / rc.BatchSize
+	batches := make([][]*model.CommunityReport, numBatches)
+	for i, report := range sorted {
+		batches[i%numBatches] = append(batches[i%numBatches], report)
+	}
+	return batches
+}
+
+// selectAnchors picks evenly spaced reports across the range of ratings assigned so far.
+func (rc *RatingCalibrator) selectAnchors(rated []ratedReport) []ReportItem {
+	if rc.AnchorCount <= 0 || len(rated) == 0 {
+		return nil
+	}
+
+	sorted := slices.Clone(rated)
+	slices.SortStableFunc(sorted, func(a, b ratedReport) int {
+		return cmp.Compare(b.rating.Rating, a.rating.Rating)
+	})
+
+	if len(sorted) > rc.AnchorCount {
+		if rc.AnchorCount == 1 {
+			sorted = sorted[len(sorted)/2 : len(sorted)/2+1]
+		} else {
+			spaced := make([]ratedReport, 0, rc.AnchorCount)
+			step := float64(len(sorted)-1) / float64(rc.AnchorCount-1)
+			for i := 0; i < rc.AnchorCount; i++ {
+				spaced = append(spaced, sorted[int(float64(i)*step+0.5)])
+			}
+			sorted = spaced
+		}
+	}
+
+	anchors := make([]ReportItem, 0, len(sorted))
+	for _, r := range sorted {
+		anchors = append(anchors, ReportItem{
+			Title:   r.report.Title,
+			Summary: reportSummary(r.report),
+			Rating:  r.rating.Rating,
+		})
+	}
+	return anchors
+}
+
+func (rc *RatingCalibrator) clamp(rating float64) float64 {
+	return min(max(rating, rc.MinRating), rc.MaxRating)
+}
+
+func reportSummary(report *model.CommunityReport) string {
+	if report.Summary != "" {
+		return report.Summary
+	}
+	return report.FullContent
+}
+
+func processResults(response string) map[string]Rating {
+	before, ok := strings.CutSuffix(strings.TrimSpace(response), prompts.DefaultCompletionDelimiter)
+	if ok {
+		response = before
+	}
+
+	ratings := make(map[string]Rating)
+	for _, part := range strings.Split(response, prompts.DefaultRecordDelimiter) {
+		trimmed := strings.TrimSpace(part)
+		if trimmed == "" {
+			continue
+		}
+
+		rating, ok := parseRecord(trimmed)
+		if !ok {
+			continue
+		}
+		ratings[rating.ReportID] = rating
+	}
+
+	return ratings
+}
+
+func parseRecord(record string) (Rating, bool) {
+	record = strings.TrimPrefix(record, "(")
+	record = strings.TrimSuffix(record, ")")
+
+	attrs := strings.Split(record, prompts.DefaultTupleDelimiter)
+	if len(attrs) < 3 {
+		return Rating{}, false
+	}
+
+	for i, v := range attrs {
+		attrs[i] = prompts.CleanString(v)
+	}
+
+	if attrs[0] != "rating" {
+		return Rating{}, false
+	}
+
+	value, err := strconv.ParseFloat(attrs[2], 64)
+	if err != nil {
+		return Rating{}, false
+	}
+
+	rating := Rating{
+		ReportID: attrs[1],
+		Rating:   value,
+	}
+	if len(attrs) > 3 {
+		rating.Explanation = attrs[3]
+	}
+
+	return rating, true
+}
@@ -0,0 +1,135 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ivanvanderbyl/graphrag-go/pkg/llm"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubLLM struct {
+	prompts   []string
+	responses []string
+	err       error // Returned once responses run out
+}
+
+func (s *stubLLM) Generate(ctx context.Context, prompt string, opts ...llm.Option) (string, error) {
+	s.prompts = append(s.prompts, prompt)
+	if len(s.responses) == 0 {
+		return "", s.err
+	}
+	resp := s.responses[0]
+	s.responses = s.responses[1:]
+	return resp, nil
+}
+
+func (s *stubLLM) Embedding(ctx context.Context, input string, opts ...llm.Option) ([]float32, error) {
+	return nil, nil
+}
+
+func TestRatingParsing(t *testing.T) {
+	a := assert.New(t)
+
+	ratings := processResults(`("rating"<|>1<|>7.5<|>"The Unity March is a large public event.")##
+("rating"<|>2<|>not a number<|>"Invalid.")##
+("entity"<|>"Unrelated"<|>"person"<|>"Should be ignored.")##
+("rating"<|>3<|>1<|>"A local book club.")<|COMPLETE|>`)
+
+	a.Len(ratings, 2)
+	a.Equal(7.5, ratings["1"].Rating)
+	a.Equal("The Unity March is a large public event.", ratings["1"].Explanation)
+	a.Equal(1.0, ratings["3"].Rating)
+}
+
+func testReports() []*model.CommunityReport {
+	reports := make([]*model.CommunityReport, 0, 4)
+	for i := range 4 {
+		reports = append(reports, &model.CommunityReport{
+			Identified: model.Identified{ID: fmt.Sprintf("report-%d", i)},
+			Title:      fmt.Sprintf("Report %d", i),
+			Summary:    fmt.Sprintf("Summary of report %d", i),
+			Rank:       float64(i),
+		})
+	}
+	return reports
+}
+
+func TestCalibrate(t *testing.T) {
+	a := assert.New(t)
+
+	reports := testReports()
+	stub := &stubLLM{responses: []string{
+		`("rating"<|>1<|>9<|>"Most important.")##("rating"<|>2<|>12<|>"Out of range.")<|COMPLETE|>`,
+		`("rating"<|>1<|>4<|>"Middling.")<|COMPLETE|>`,
+		`("rating"<|>1<|>0.5<|>"Least important.")<|COMPLETE|>`,
+	}}
+
+	calibrator := NewRatingCalibrator(stub, WithBatchSize(2), WithAnchorCount(1))
+	err := calibrator.Calibrate(context.TODO(), reports)
+	a.NoError(err)
+	a.Len(stub.prompts, 3)
+
+	// Batches are dealt from the highest rank down: [3, 1] then [2, 0]
+	a.Equal(9.0, reports[3].Rank)
+	a.Equal("Most important.", reports[3].RankExplanation)
+	a.Equal(10.0, reports[1].Rank)
+	a.Equal(4.0, reports[2].Rank)
+
+	// Reports missing from a response are rated again with the same anchors
+	a.Equal(0.5, reports[0].Rank)
+	a.Equal("Least important.", reports[0].RankExplanation)
+	a.Contains(stub.prompts[2], "Report 0")
+	a.NotContains(stub.prompts[2], "Report 2")
+	a.Contains(stub.prompts[2], "rating: 9.0")
+
+	a.NotContains(stub.prompts[0], "Reference Reports:")
+	a.Contains(stub.prompts[1], "Reference Reports:")
+	a.Contains(stub.prompts[1], "rating: 9.0")
+}
+
+func TestCalibrateBatchFails(t *testing.T) {
+	a := assert.New(t)
+
+	reports := testReports()
+	stub := &stubLLM{
+		responses: []string{`("rating"<|>1<|>9<|>"Most important.")##("rating"<|>2<|>8<|>"Important.")<|COMPLETE|>`},
+		err:       errors.New("rate limited"),
+	}
+
+	calibrator := NewRatingCalibrator(stub, WithBatchSize(2))
+	err := calibrator.Calibrate(context.TODO(), reports)
+	a.ErrorIs(err, stub.err)
+
+	// The first batch was rated, but no ranks change unless every batch succeeds
+	for i, report := range reports {
+		a.Equal(float64(i), report.Rank)
+		a.Empty(report.RankExplanation)
+	}
+}
+
+func TestCalibrateUnrated(t *testing.T) {
+	a := assert.New(t)
+
+	reports := testReports()
+	stub := &stubLLM{responses: []string{
+		`("rating"<|>1<|>9<|>"Most important.")##("rating"<|>2<|>8<|>"Important.")<|COMPLETE|>`,
+		`("rating"<|>1<|>4<|>"Middling.")<|COMPLETE|>`,
+		`<|COMPLETE|>`,
+	}}
+
+	calibrator := NewRatingCalibrator(stub, WithBatchSize(2), WithMaxRetries(1))
+	err := calibrator.Calibrate(context.TODO(), reports)
+
+	var unrated *UnratedError
+	a.ErrorAs(err, &unrated)
+	a.Equal([]string{"report-0"}, unrated.ReportIDs)
+	a.Len(stub.prompts, 3)
+
+	for i, report := range reports {
+		a.Equal(float64(i), report.Rank)
+	}
+}
@@ -70,6 +70,13 @@ func WithMaxInputTokens(maxInputTokens int) Option {
 	}
 }
 
+// WithStageModels summarizes with the summarization model
+func WithStageModels(models llm.StageModels) Option {
+	return func(se *SummarizeExtractor) {
+		se.LLM = models.ForStage(se.LLM, llm.StageSummarization)
+	}
+}
+
 // NewSummarizeExtractor creates a new SummarizeExtractor
 func NewSummarizeExtractor(llm llm.LLM, opts ...Option) *SummarizeExtractor {
 	s := &SummarizeExtractor{
@@ -16,6 +16,9 @@ import (
 	"github.com/pkg/errors"
 )
 
+// Header set on responses served from the cache rather than the network
+const CacheHitHeader = "X-Cache-Hit"
+
 type CacheTransport struct {
 	Transport       http.RoundTripper
 	CacheDomains    []string
@@ -40,6 +43,7 @@ func (t *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	if t.requestHasCachedResponse(cacheKey) {
 		cachedResp, err := t.getCachedResponse(cacheKey)
 		if err == nil && !t.isExpired(cachedResp) {
+			cachedResp.Header.Set(CacheHitHeader, "true")
 			return cachedResp, nil
 		}
 	}
@@ -45,6 +45,7 @@ func TestCachingResponses(t *testing.T) {
 		req            *http.Request
 		expected       int
 		shouldBeCached bool
+		cacheHit       bool
 	}{
 		{
 			name:           "success",
@@ -57,6 +58,7 @@ func TestCachingResponses(t *testing.T) {
 			req:            httptest.NewRequest("POST", server.URL+"/success", bytes.NewBufferString("Completion Request")),
 			expected:       http.StatusOK,
 			shouldBeCached: true,
+			cacheHit:       true,
 		},
 		{
 			name:           "error",
@@ -76,6 +78,7 @@ func TestCachingResponses(t *testing.T) {
 			a.NoError(err)
 			a.NotNil(resp)
 			a.Equal(tt.expected, resp.StatusCode)
+			a.Equal(tt.cacheHit, resp.Header.Get(llm.CacheHitHeader) != "")
 
 			_, err = os.Stat(filepath.Join(tmpDir, key))
 			if tt.shouldBeCached {
@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Capability describes what a model can be used for
+type Capability int
+
+const (
+	CapabilityCompletion Capability = 1 << iota // Generating text from a prompt
+	CapabilityEmbedding                         // Generating embeddings from an input
+	CapabilityDimensions                        // Choosing the number of embedding dimensions
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Capability{
+		openai.GPT4o:                   CapabilityCompletion,
+		openai.GPT4oMini:               CapabilityCompletion,
+		openai.GPT4Turbo:               CapabilityCompletion,
+		openai.GPT4:                    CapabilityCompletion,
+		openai.GPT3Dot5Turbo:           CapabilityCompletion,
+		string(openai.SmallEmbedding3): CapabilityEmbedding | CapabilityDimensions,
+		string(openai.LargeEmbedding3): CapabilityEmbedding | CapabilityDimensions,
+		string(openai.AdaEmbeddingV2):  CapabilityEmbedding,
+	}
+
+	// Dated snapshots such as gpt-4o-2024-08-06 or gpt-3.5-turbo-0125
+	snapshotSuffix = regexp.MustCompile(`-(\d{4}-\d{2}-\d{2}|\d{4})$`)
+)
+
+// Has reports whether all of the given capabilities are present
+func (c Capability) Has(other Capability) bool {
+	return c&other == other
+}
+
+func (c Capability) String() string {
+	switch c {
+	case CapabilityCompletion:
+		return "completion"
+	case CapabilityEmbedding:
+		return "embedding"
+	case CapabilityDimensions:
+		return "dimensions"
+	default:
+		return fmt.Sprintf("Capability(%d)", int(c))
+	}
+}
+
+// ParseCapability returns the capability with the given name, as returned by String
+func ParseCapability(name string) (Capability, error) {
+	for _, c := range []Capability{CapabilityCompletion, CapabilityEmbedding, CapabilityDimensions} {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown capability %q", name)
+}
+
+// UnmarshalText parses a capability name, so capabilities can be declared in config files
+func (c *Capability) UnmarshalText(text []byte) error {
+	parsed, err := ParseCapability(string(text))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// RegisterModel adds a model to the capability registry, replacing any existing entry
+func RegisterModel(model string, capabilities Capability) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[model] = capabilities
+}
+
+// ModelCapabilities returns the capabilities of a registered model. Dated snapshots of a
+// registered model, such as gpt-4o-mini-2024-07-18, share the capabilities of that model.
+func ModelCapabilities(model string) (Capability, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	if c, ok := registry[model]; ok {
+		return c, true
+	}
+	c, ok := registry[snapshotSuffix.ReplaceAllString(model, "")]
+	return c, ok
+}
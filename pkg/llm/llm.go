@@ -27,6 +27,7 @@ type (
 		MaxTokens      int     // Max tokens to generate when generating text
 		Dimensions     int     // Embedding dimensions to generate when embedding
 		Model          string  // Model to use
+		EmbeddingModel string  // Model to use when embedding
 		SystemPrompt   string  // System prompt for completion
 		Temperature    float64 // Temperature for sampling
 		UseCache       bool    // Enable HTTP Request caching
@@ -68,6 +69,13 @@ func WithModel(model string) Option {
 	}
 }
 
+// WithEmbeddingModel sets the embedding model
+func WithEmbeddingModel(model string) Option {
+	return func(o *Options) {
+		o.EmbeddingModel = model
+	}
+}
+
 // WithSystemPrompt sets the system prompt
 func WithSystemPrompt(systemPrompt string) Option {
 	return func(o *Options) {
@@ -5,22 +5,30 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"sync"
 
 	"github.com/sashabaranov/go-openai"
 )
 
 type OpenAI struct {
 	options *Options
+
+	mu    sync.Mutex
+	usage Usage
 }
 
-var _ LLM = (*OpenAI)(nil)
+var (
+	_ StreamingLLM  = (*OpenAI)(nil)
+	_ UsageReporter = (*OpenAI)(nil)
+)
 
 func NewOpenAI(opts ...Option) LLM {
 	options := &Options{
-		Model:      openai.GPT4o,
-		MaxTokens:  4_000,
-		APIKey:     os.Getenv("OPENAI_API_KEY"),
-		Dimensions: DefaultDimensions,
+		Model:          openai.GPT4o,
+		EmbeddingModel: string(openai.SmallEmbedding3),
+		MaxTokens:      4_000,
+		APIKey:         os.Getenv("OPENAI_API_KEY"),
+		Dimensions:     DefaultDimensions,
 	}
 	for _, opt := range opts {
 		opt(options)
@@ -76,7 +84,7 @@ func (o *OpenAI) Generate(ctx context.Context, prompt string, opts ...Option) (s
 	}
 
 	req := openai.ChatCompletionRequest{
-		Model:       openai.GPT4o,
+		Model:       options.Model,
 		MaxTokens:   options.MaxTokens,
 		Messages:    msgs,
 		Temperature: float32(options.Temperature),
@@ -88,6 +96,8 @@ func (o *OpenAI) Generate(ctx context.Context, prompt string, opts ...Option) (s
 		return "", err
 	}
 
+	o.addUsage(resp.Header(), resp.Usage)
+
 	if len(resp.Choices) == 0 {
 		return "", fmt.Errorf("no choices returned")
 	}
@@ -140,7 +150,7 @@ func (o *OpenAI) Stream(ctx context.Context, prompt string, opts ...Option) (<-c
 	}
 
 	stream, err := client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
-		Model:       openai.GPT4o,
+		Model:       options.Model,
 		MaxTokens:   options.MaxTokens,
 		Messages:    msgs,
 		Temperature: float32(options.Temperature),
@@ -149,6 +159,10 @@ func (o *OpenAI) Stream(ctx context.Context, prompt string, opts ...Option) (<-c
 	if err != nil {
 		return nil, err
 	}
+
+	// Streamed responses don't report token usage
+	o.addUsage(stream.Header(), openai.Usage{})
+
 	recvChan := make(chan string)
 
 	go func() {
@@ -182,6 +196,7 @@ func (o *OpenAI) Embedding(ctx context.Context, input string, opts ...Option) ([
 		CacheDirectory: o.options.CacheDirectory,
 		UseCache:       o.options.UseCache,
 		Model:          o.options.Model,
+		EmbeddingModel: o.options.EmbeddingModel,
 		MaxTokens:      o.options.MaxTokens,
 		Temperature:    o.options.Temperature,
 		SystemPrompt:   o.options.SystemPrompt,
@@ -207,20 +222,51 @@ func (o *OpenAI) Embedding(ctx context.Context, input string, opts ...Option) ([
 	client := openai.NewClientWithConfig(cfg)
 
 	req := openai.EmbeddingRequest{
-		Model:          openai.SmallEmbedding3,
+		Model:          openai.EmbeddingModel(options.EmbeddingModel),
 		EncodingFormat: openai.EmbeddingEncodingFormatFloat,
 		Dimensions:     options.Dimensions,
 		Input:          input,
 	}
 
+	// Older embedding models have a fixed size and reject requests that set dimensions
+	if capabilities, ok := ModelCapabilities(options.EmbeddingModel); ok && !capabilities.Has(CapabilityDimensions) {
+		req.Dimensions = 0
+	}
+
 	resp, err := client.CreateEmbeddings(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
+	o.addUsage(resp.Header(), resp.Usage)
+
 	if len(resp.Data) == 0 {
 		return nil, fmt.Errorf("no embeddings returned")
 	}
 
 	return resp.Data[0].Embedding, nil
 }
+
+// Usage returns the total usage of all requests made so far
+func (o *OpenAI) Usage() Usage {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.usage
+}
+
+// addUsage records a response, counting responses replayed from the cache separately as no
+// tokens were used
+func (o *OpenAI) addUsage(header http.Header, u openai.Usage) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if header.Get(CacheHitHeader) != "" {
+		o.usage.CachedRequests++
+		return
+	}
+
+	o.usage.Requests++
+	o.usage.PromptTokens += u.PromptTokens
+	o.usage.CompletionTokens += u.CompletionTokens
+	o.usage.TotalTokens += u.TotalTokens
+}
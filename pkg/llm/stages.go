@@ -0,0 +1,168 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// Stage identifies a step of indexing or querying that makes LLM calls
+type Stage string
+
+const (
+	StageExtraction    Stage = "extraction"
+	StageSummarization Stage = "summarization"
+	StageReports       Stage = "reports"
+	StageEmbedding     Stage = "embedding"
+	StageQueryMap      Stage = "query_map"
+	StageQueryReduce   Stage = "query_reduce"
+)
+
+var stageFallbacks = map[Stage]Stage{
+	StageQueryMap: StageQueryReduce,
+}
+
+// StageModels assigns a model to each stage, for example a cheap model for extraction and a
+// strong model for the reduce phase of a query. Stages without a model use the default model of
+// the LLM they are run with.
+type StageModels map[Stage]string
+
+// stageModelsFile is the file format read by LoadStageModels
+type stageModelsFile struct {
+	Stages StageModels             `json:"stages"`
+	Models map[string][]Capability `json:"models"`
+}
+
+// LoadStageModels reads stage models from a JSON file and validates them. The file maps stage
+// names to models under "stages", and may register models missing from the capability registry
+// under "models":
+//
+//	{
+//		"stages": {"extraction": "my-model", "query_reduce": "gpt-4o"},
+//		"models": {"my-model": ["completion"]}
+//	}
+func LoadStageModels(path string) (StageModels, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file stageModelsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse stage models: %w", err)
+	}
+
+	for model, capabilities := range file.Models {
+		var c Capability
+		for _, capability := range capabilities {
+			c |= capability
+		}
+		RegisterModel(model, c)
+	}
+
+	if err := file.Stages.Validate(); err != nil {
+		return nil, err
+	}
+
+	return file.Stages, nil
+}
+
+// Model returns the model assigned to a stage. The query map phase falls back to the query
+// reduce model.
+func (sm StageModels) Model(stage Stage) string {
+	if model, ok := sm[stage]; ok && model != "" {
+		return model
+	}
+	if fallback, ok := stageFallbacks[stage]; ok {
+		return sm.Model(fallback)
+	}
+	return ""
+}
+
+// Validate checks each assigned model against the capability registry. Stages assigned an empty
+// model are treated as unset.
+func (sm StageModels) Validate() error {
+	var errs []error
+	for stage, model := range sm {
+		var required Capability
+		switch stage {
+		case StageEmbedding:
+			required = CapabilityEmbedding
+		case StageExtraction, StageSummarization, StageReports, StageQueryMap, StageQueryReduce:
+			required = CapabilityCompletion
+		default:
+			errs = append(errs, fmt.Errorf("unknown stage %q", stage))
+			continue
+		}
+
+		if model == "" {
+			continue
+		}
+
+		capabilities, ok := ModelCapabilities(model)
+		if !ok {
+			errs = append(errs, fmt.Errorf("stage %q: unknown model %q", stage, model))
+			continue
+		}
+
+		if !capabilities.Has(required) {
+			errs = append(errs, fmt.Errorf("stage %q: model %q does not support %s", stage, model, required))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ForStage returns an LLM which uses the models assigned to stage for generation, and the
+// embedding model for embeddings. The returned LLM is a StreamingLLM when l is.
+func (sm StageModels) ForStage(l LLM, stage Stage) LLM {
+	opts := make([]Option, 0, 2)
+	if model := sm.Model(stage); model != "" && stage != StageEmbedding {
+		opts = append(opts, WithModel(model))
+	}
+	if model := sm.Model(StageEmbedding); model != "" {
+		opts = append(opts, WithEmbeddingModel(model))
+	}
+
+	s := &stageLLM{
+		llm:  l,
+		opts: opts,
+	}
+
+	if streaming, ok := l.(StreamingLLM); ok {
+		return &streamingStageLLM{
+			stageLLM:  s,
+			streaming: streaming,
+		}
+	}
+
+	return s
+}
+
+type stageLLM struct {
+	llm  LLM
+	opts []Option
+}
+
+var _ LLM = (*stageLLM)(nil)
+
+func (s *stageLLM) Generate(ctx context.Context, prompt string, opts ...Option) (string, error) {
+	return s.llm.Generate(ctx, prompt, slices.Concat(s.opts, opts)...)
+}
+
+func (s *stageLLM) Embedding(ctx context.Context, input string, opts ...Option) ([]float32, error) {
+	return s.llm.Embedding(ctx, input, slices.Concat(s.opts, opts)...)
+}
+
+type streamingStageLLM struct {
+	*stageLLM
+	streaming StreamingLLM
+}
+
+var _ StreamingLLM = (*streamingStageLLM)(nil)
+
+func (s *streamingStageLLM) Stream(ctx context.Context, prompt string, opts ...Option) (<-chan string, error) {
+	return s.streaming.Stream(ctx, prompt, slices.Concat(s.opts, opts)...)
+}
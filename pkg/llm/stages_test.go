@@ -0,0 +1,176 @@
+package llm_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ivanvanderbyl/graphrag-go/pkg/llm"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingLLM struct {
+	options llm.Options
+}
+
+func (r *recordingLLM) Generate(ctx context.Context, prompt string, opts ...llm.Option) (string, error) {
+	for _, opt := range opts {
+		opt(&r.options)
+	}
+	return "", nil
+}
+
+func (r *recordingLLM) Embedding(ctx context.Context, input string, opts ...llm.Option) ([]float32, error) {
+	for _, opt := range opts {
+		opt(&r.options)
+	}
+	return nil, nil
+}
+
+type recordingStreamingLLM struct {
+	recordingLLM
+}
+
+func (r *recordingStreamingLLM) Stream(ctx context.Context, prompt string, opts ...llm.Option) (<-chan string, error) {
+	for _, opt := range opts {
+		opt(&r.options)
+	}
+	ch := make(chan string)
+	close(ch)
+	return ch, nil
+}
+
+func TestStageModelFallbacks(t *testing.T) {
+	a := assert.New(t)
+
+	models := llm.StageModels{
+		llm.StageExtraction:  "gpt-4o-mini",
+		llm.StageQueryReduce: "gpt-4o",
+		llm.StageQueryMap:    "gpt-3.5-turbo",
+	}
+
+	a.Equal("gpt-3.5-turbo", models.Model(llm.StageQueryMap))
+	a.Equal("", models.Model(llm.StageSummarization))
+
+	delete(models, llm.StageQueryMap)
+	a.Equal("gpt-4o", models.Model(llm.StageQueryMap))
+}
+
+func TestStageModelValidation(t *testing.T) {
+	a := assert.New(t)
+
+	a.NoError(llm.StageModels{
+		llm.StageExtraction: "gpt-4o-mini",
+		llm.StageEmbedding:  "text-embedding-3-large",
+	}.Validate())
+
+	err := llm.StageModels{
+		llm.StageEmbedding:   "gpt-4o",
+		llm.StageQueryReduce: "not-a-model",
+		"indexing":           "gpt-4o",
+	}.Validate()
+	a.ErrorContains(err, `stage "embedding": model "gpt-4o" does not support embedding`)
+	a.ErrorContains(err, `stage "query_reduce": unknown model "not-a-model"`)
+	a.ErrorContains(err, `unknown stage "indexing"`)
+
+	// Empty assignments are unset, matching Model
+	a.NoError(llm.StageModels{llm.StageSummarization: ""}.Validate())
+
+	llm.RegisterModel("local-model", llm.CapabilityCompletion|llm.CapabilityEmbedding)
+	a.NoError(llm.StageModels{
+		llm.StageReports:   "local-model",
+		llm.StageEmbedding: "local-model",
+	}.Validate())
+}
+
+func TestForStage(t *testing.T) {
+	a := assert.New(t)
+
+	models := llm.StageModels{
+		llm.StageExtraction: "gpt-4o-mini",
+		llm.StageEmbedding:  "text-embedding-3-large",
+	}
+
+	rec := &recordingLLM{}
+	extraction := models.ForStage(rec, llm.StageExtraction)
+
+	_, isStreaming := extraction.(llm.StreamingLLM)
+	a.False(isStreaming)
+
+	_, err := extraction.Generate(context.TODO(), "prompt")
+	a.NoError(err)
+	a.Equal("gpt-4o-mini", rec.options.Model)
+	a.Equal("text-embedding-3-large", rec.options.EmbeddingModel)
+
+	// Options passed to the call take precedence over the stage model
+	_, err = extraction.Generate(context.TODO(), "prompt", llm.WithModel("gpt-4o"))
+	a.NoError(err)
+	a.Equal("gpt-4o", rec.options.Model)
+}
+
+func TestForStageStreaming(t *testing.T) {
+	a := assert.New(t)
+
+	rec := &recordingStreamingLLM{}
+	reduce := llm.StageModels{llm.StageQueryReduce: "gpt-4o"}.ForStage(rec, llm.StageQueryReduce)
+
+	streaming, ok := reduce.(llm.StreamingLLM)
+	a.True(ok)
+
+	_, err := streaming.Stream(context.TODO(), "prompt")
+	a.NoError(err)
+	a.Equal("gpt-4o", rec.options.Model)
+}
+
+func TestLoadStageModels(t *testing.T) {
+	a := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "models.json")
+	a.NoError(os.WriteFile(path, []byte(`{"stages": {"extraction": "gpt-4o-mini", "query_reduce": "gpt-4o"}}`), 0644))
+
+	models, err := llm.LoadStageModels(path)
+	a.NoError(err)
+	a.Equal("gpt-4o-mini", models.Model(llm.StageExtraction))
+
+	a.NoError(os.WriteFile(path, []byte(`{"stages": {"embedding": "gpt-4o"}}`), 0644))
+	_, err = llm.LoadStageModels(path)
+	a.ErrorContains(err, "does not support embedding")
+
+	// Models missing from the registry can be declared alongside the stages
+	a.NoError(os.WriteFile(path, []byte(`{
+		"stages": {"summarization": "fine-tuned-summarizer", "embedding": "fine-tuned-summarizer"},
+		"models": {"fine-tuned-summarizer": ["completion", "embedding"]}
+	}`), 0644))
+	models, err = llm.LoadStageModels(path)
+	a.NoError(err)
+	a.Equal("fine-tuned-summarizer", models.Model(llm.StageSummarization))
+
+	a.NoError(os.WriteFile(path, []byte(`{"models": {"other-model": ["chat"]}}`), 0644))
+	_, err = llm.LoadStageModels(path)
+	a.ErrorContains(err, `unknown capability "chat"`)
+}
+
+func TestModelSnapshots(t *testing.T) {
+	a := assert.New(t)
+
+	a.NoError(llm.StageModels{
+		llm.StageExtraction:    "gpt-4o-mini-2024-07-18",
+		llm.StageQueryReduce:   "gpt-4o-2024-08-06",
+		llm.StageSummarization: "gpt-3.5-turbo-0125",
+	}.Validate())
+
+	a.Error(llm.StageModels{llm.StageExtraction: "gpt-4o-latest"}.Validate())
+}
+
+func TestEmbeddingDimensionsCapability(t *testing.T) {
+	a := assert.New(t)
+
+	small, ok := llm.ModelCapabilities("text-embedding-3-small")
+	a.True(ok)
+	a.True(small.Has(llm.CapabilityEmbedding | llm.CapabilityDimensions))
+
+	ada, ok := llm.ModelCapabilities("text-embedding-ada-002")
+	a.True(ok)
+	a.False(ada.Has(llm.CapabilityDimensions))
+}
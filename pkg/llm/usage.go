@@ -0,0 +1,29 @@
+package llm
+
+type (
+	// Usage counts the requests made to an LLM and the tokens they used. Responses replayed from
+	// the cache used no tokens, so they are only counted in CachedRequests.
+	Usage struct {
+		Requests         int `json:"requests"`
+		CachedRequests   int `json:"cached_requests"`
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	}
+
+	// UsageReporter is implemented by LLMs that track their usage
+	UsageReporter interface {
+		Usage() Usage
+	}
+)
+
+// Sub returns the usage since an earlier snapshot, for example the usage of a single stage
+func (u Usage) Sub(earlier Usage) Usage {
+	return Usage{
+		Requests:         u.Requests - earlier.Requests,
+		CachedRequests:   u.CachedRequests - earlier.CachedRequests,
+		PromptTokens:     u.PromptTokens - earlier.PromptTokens,
+		CompletionTokens: u.CompletionTokens - earlier.CompletionTokens,
+		TotalTokens:      u.TotalTokens - earlier.TotalTokens,
+	}
+}
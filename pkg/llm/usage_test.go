@@ -0,0 +1,24 @@
+package llm
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAIUsage(t *testing.T) {
+	a := assert.New(t)
+
+	o := &OpenAI{}
+	o.addUsage(http.Header{}, openai.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120})
+	before := o.Usage()
+
+	// Responses replayed from the cache are counted, but their tokens are not
+	o.addUsage(http.Header{CacheHitHeader: []string{"true"}}, openai.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120})
+	o.addUsage(http.Header{}, openai.Usage{PromptTokens: 50, CompletionTokens: 10, TotalTokens: 60})
+
+	a.Equal(Usage{Requests: 2, CachedRequests: 1, PromptTokens: 150, CompletionTokens: 30, TotalTokens: 180}, o.Usage())
+	a.Equal(Usage{Requests: 1, CachedRequests: 1, PromptTokens: 50, CompletionTokens: 10, TotalTokens: 60}, o.Usage().Sub(before))
+}
@@ -0,0 +1,18 @@
+package model
+
+// CommunityReport represents a summary report for a community of entities in the graph.
+type CommunityReport struct {
+	Identified
+	Title       string `json:"title"`
+	CommunityID string `json:"community_id"`
+	Summary     string `json:"summary,omitempty"`
+	FullContent string `json:"full_content,omitempty"`
+
+	// Rank is the importance rating of the report, used to shortlist reports during global search.
+	Rank            float64 `json:"rank,omitempty"`
+	RankExplanation string  `json:"rank_explanation,omitempty"`
+
+	SummaryEmbedding     []float64      `json:"summary_embedding,omitempty"`
+	FullContentEmbedding []float64      `json:"full_content_embedding,omitempty"`
+	Attributes           map[string]any `json:"attributes,omitempty"`
+}
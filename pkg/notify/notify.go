@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ivanvanderbyl/graphrag-go/pkg/llm"
+)
+
+type EventType string
+
+// Stage names a step of a pipeline run, such as extraction or writing to a graph database
+type Stage string
+
+const (
+	EventRunStarted     EventType = "run.started"
+	EventRunCompleted   EventType = "run.completed"
+	EventRunFailed      EventType = "run.failed"
+	EventStageCompleted EventType = "stage.completed"
+	EventStageFailed    EventType = "stage.failed"
+)
+
+type (
+	// Notifier delivers pipeline lifecycle events to an external system
+	Notifier interface {
+		Notify(ctx context.Context, event Event) error
+	}
+
+	Event struct {
+		Type  EventType `json:"type"`
+		RunID string    `json:"run_id"`
+		Stage Stage     `json:"stage,omitempty"`
+		Time  time.Time `json:"time"`
+		Error string    `json:"error,omitempty"`
+		Usage *Usage    `json:"usage,omitempty"`
+	}
+
+	// Usage summarises the work done by a stage or a whole run
+	Usage struct {
+		llm.Usage
+		Duration time.Duration `json:"duration"`
+	}
+
+	// Multi sends each event to every notifier, returning all delivery errors
+	Multi []Notifier
+
+	// ChannelNotifier writes events to a channel, blocking until the event is received or the
+	// context is done
+	ChannelNotifier chan<- Event
+)
+
+func (m Multi) Notify(ctx context.Context, event Event) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c ChannelNotifier) Notify(ctx context.Context, event Event) error {
+	select {
+	case c <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run emits the lifecycle events of a single pipeline run
+type Run struct {
+	ID       string
+	notifier Notifier
+}
+
+func NewRun(notifier Notifier, runID string) *Run {
+	return &Run{
+		ID:       runID,
+		notifier: notifier,
+	}
+}
+
+// Start notifies that the run has started
+func (r *Run) Start(ctx context.Context) error {
+	return r.notify(ctx, Event{Type: EventRunStarted})
+}
+
+// StageCompleted notifies that a stage has completed, with the usage of that stage if known
+func (r *Run) StageCompleted(ctx context.Context, stage Stage, usage *Usage) error {
+	return r.notify(ctx, Event{Type: EventStageCompleted, Stage: stage, Usage: usage})
+}
+
+// StageFailed notifies that a stage has failed. A nil err is sent without an error message.
+func (r *Run) StageFailed(ctx context.Context, stage Stage, err error) error {
+	return r.notify(ctx, Event{Type: EventStageFailed, Stage: stage, Error: errorMessage(err)})
+}
+
+// Complete notifies that the run has completed, with the usage summary of the whole run
+func (r *Run) Complete(ctx context.Context, usage *Usage) error {
+	return r.notify(ctx, Event{Type: EventRunCompleted, Usage: usage})
+}
+
+// Fail notifies that the run has failed. A nil err is sent without an error message.
+func (r *Run) Fail(ctx context.Context, err error) error {
+	return r.notify(ctx, Event{Type: EventRunFailed, Error: errorMessage(err)})
+}
+
+func (r *Run) notify(ctx context.Context, event Event) error {
+	event.RunID = r.ID
+	event.Time = time.Now().UTC()
+	return r.notifier.Notify(ctx, event)
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,103 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ivanvanderbyl/graphrag-go/pkg/llm"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/notify"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	r := require.New(t)
+
+	received := make(chan notify.Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if !notify.Verify("secret", req.Header.Get(notify.TimestampHeader), body, req.Header.Get(notify.SignatureHeader), notify.DefaultMaxAge) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var event notify.Event
+		if err := json.Unmarshal(body, &event); err != nil || string(event.Type) != req.Header.Get(notify.EventHeader) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	run := notify.NewRun(notify.NewWebhookNotifier(server.URL, notify.WithSecret("secret")), "run-1")
+	err := run.Complete(context.TODO(), &notify.Usage{Usage: llm.Usage{Requests: 3, TotalTokens: 1200}, Duration: time.Second})
+	r.NoError(err)
+
+	event := <-received
+	r.Equal(notify.EventRunCompleted, event.Type)
+	r.Equal("run-1", event.RunID)
+	r.Equal(1200, event.Usage.TotalTokens)
+
+	// Requests signed with the wrong secret are rejected by the receiver
+	wrongSecret := notify.NewWebhookNotifier(server.URL, notify.WithSecret("wrong"))
+	err = wrongSecret.Notify(context.TODO(), notify.Event{Type: notify.EventRunStarted})
+	r.ErrorContains(err, "webhook returned status 401")
+}
+
+func TestVerify(t *testing.T) {
+	r := require.New(t)
+
+	body := []byte(`{"type":"run.started"}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	r.True(notify.Verify("secret", now, body, notify.Sign("secret", now, body), notify.DefaultMaxAge))
+	r.False(notify.Verify("secret", now, body, notify.Sign("wrong", now, body), notify.DefaultMaxAge))
+	r.False(notify.Verify("secret", "yesterday", body, notify.Sign("secret", "yesterday", body), 0))
+
+	// A replayed request is rejected once it is older than maxAge, unless the check is disabled
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	r.False(notify.Verify("secret", stale, body, notify.Sign("secret", stale, body), notify.DefaultMaxAge))
+	r.True(notify.Verify("secret", stale, body, notify.Sign("secret", stale, body), 0))
+}
+
+func TestChannelNotifier(t *testing.T) {
+	r := require.New(t)
+
+	events := make(chan notify.Event, 4)
+	run := notify.NewRun(notify.Multi{notify.ChannelNotifier(events)}, "run-1")
+
+	r.NoError(run.Start(context.TODO()))
+	r.NoError(run.StageCompleted(context.TODO(), "extraction", nil))
+	r.NoError(run.StageFailed(context.TODO(), "write_graph", errors.New("connection refused")))
+	r.NoError(run.Fail(context.TODO(), nil))
+
+	r.Equal(notify.EventRunStarted, (<-events).Type)
+	r.Equal(notify.Stage("extraction"), (<-events).Stage)
+
+	failed := <-events
+	r.Equal(notify.EventStageFailed, failed.Type)
+	r.Equal(notify.Stage("write_graph"), failed.Stage)
+	r.Equal("connection refused", failed.Error)
+
+	// A nil error is sent without a message rather than panicking
+	runFailed := <-events
+	r.Equal(notify.EventRunFailed, runFailed.Type)
+	r.Empty(runFailed.Error)
+
+	// A full channel stops blocking once the context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	full := make(chan notify.Event)
+	r.ErrorIs(notify.ChannelNotifier(full).Notify(ctx, notify.Event{}), context.Canceled)
+}
@@ -3,6 +3,8 @@ package prompts
 import (
 	"bytes"
 	"embed"
+	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 )
@@ -11,8 +13,11 @@ import (
 var promptFS embed.FS
 
 const (
-	EntitiesTemplate = "entities"
-	ClaimsTemplate   = "claims"
+	EntitiesTemplate      = "entities"
+	ClaimsTemplate        = "claims"
+	ReportRatingsTemplate = "report_ratings"
+	GlobalMapTemplate     = "global_map"
+	GlobalReduceTemplate  = "global_reduce"
 )
 
 // Default delimiters
@@ -40,6 +45,19 @@ var DefaultPromptData = PromptData{
 	CompletionDelimiter: DefaultCompletionDelimiter,
 }
 
+var controlChars = regexp.MustCompile(`[\x00-\x1f\x7f-\x9f]`)
+
+// CleanString trims and unquotes a field parsed from a delimited LLM response, and strips any
+// control characters.
+func CleanString(str string) string {
+	str = strings.TrimSpace(str)
+	cleaned, err := strconv.Unquote(str)
+	if err != nil {
+		cleaned = str
+	}
+	return controlChars.ReplaceAllString(cleaned, "")
+}
+
 func joinStrings(strs []string) string {
 	return strings.Join(strs, ", ")
 }
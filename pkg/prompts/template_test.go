@@ -20,3 +20,11 @@ func TestLoadingAndRendering(t *testing.T) {
 
 	t.Log(result)
 }
+
+func TestCleanString(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal("The EPA is a regulator.", CleanString(` "The EPA is a regulator." `))
+	a.Equal(`Unbalanced quote."`, CleanString(`Unbalanced quote."`))
+	a.Equal("Line oneLine two", CleanString("Line one\nLine two"))
+}
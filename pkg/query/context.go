@@ -0,0 +1,215 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ivanvanderbyl/graphrag-go/pkg/extractors/entity"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/model"
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// Max token size of the context included in a query prompt
+const DefaultMaxContextTokens = 8_000
+
+type (
+	// ContextBuilder renders entities, text units and reports into context tables for query
+	// prompts, removing duplicate content before the token budget is applied.
+	ContextBuilder struct {
+		MaxTokens    int
+		Deduplicator *Deduplicator
+		TokenCounter TokenCounter // Counts tokens with TiktokenCounter when nil
+	}
+
+	// TokenCounter returns the number of tokens in text
+	TokenCounter func(text string) int
+
+	ContextOption func(*ContextBuilder)
+
+	ContextResult struct {
+		Text  string
+		Trace *Trace // Only set when building with WithDebug
+	}
+)
+
+func NewContextBuilder(opts ...ContextOption) *ContextBuilder {
+	cb := &ContextBuilder{
+		MaxTokens:    DefaultMaxContextTokens,
+		Deduplicator: &Deduplicator{},
+	}
+	for _, opt := range opts {
+		opt(cb)
+	}
+	return cb
+}
+
+// WithMaxContextTokens sets the max context tokens
+func WithMaxContextTokens(maxTokens int) ContextOption {
+	return func(cb *ContextBuilder) {
+		cb.MaxTokens = maxTokens
+	}
+}
+
+// WithTokenCounter sets the function used to count tokens against the budget
+func WithTokenCounter(counter TokenCounter) ContextOption {
+	return func(cb *ContextBuilder) {
+		cb.TokenCounter = counter
+	}
+}
+
+// TiktokenCounter counts tokens with the cl100k_base encoding used by OpenAI models. The encoding
+// is downloaded on first use.
+func TiktokenCounter() (TokenCounter, error) {
+	enc, err := tiktoken.GetEncoding(tiktoken.MODEL_CL100K_BASE)
+	if err != nil {
+		return nil, err
+	}
+	return func(text string) int {
+		return len(enc.Encode(text, nil, nil))
+	}, nil
+}
+
+// WithDeduplicator sets the deduplicator, or disables deduplication when nil
+func WithDeduplicator(d *Deduplicator) ContextOption {
+	return func(cb *ContextBuilder) {
+		cb.Deduplicator = d
+	}
+}
+
+// Build renders entities followed by text units, in the order given, until the token budget is
+// exhausted. Callers should order both by relevance to the query. With WithDebug, the result
+// includes a trace of each item that made it into the context and its token count.
+func (cb *ContextBuilder) Build(entities []*entity.Entity, units []*model.TextUnit, opts ...SearchOption) (*ContextResult, error) {
+	options := &SearchOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	var trace *Trace
+	if options.Debug {
+		trace = &Trace{}
+	}
+
+	if cb.Deduplicator != nil {
+		entities = cb.Deduplicator.Entities(entities)
+		units = cb.Deduplicator.TextUnits(units)
+	}
+
+	countTokens, err := cb.tokenCounter()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(strings.Builder)
+	usedTokens := 0
+
+	// add writes text to the context if it fits within the remaining budget, returning its
+	// token count
+	add := func(text string) (int, bool) {
+		tokens := countTokens(text)
+		if usedTokens+tokens > cb.MaxTokens {
+			return 0, false
+		}
+		usedTokens += tokens
+		buf.WriteString(text)
+		return tokens, true
+	}
+
+	// header adds a table header, returning false when it doesn't fit
+	header := func(text string) bool {
+		_, ok := add(text)
+		return ok
+	}
+
+	result := &ContextResult{Trace: trace}
+
+	if len(entities) > 0 && header("-----Entities-----\nid|entity|description\n") {
+		for i, e := range entities {
+			tokens, ok := add(fmt.Sprintf("%d|%s|%s\n", i+1, e.Name, e.Description))
+			if !ok {
+				result.Text = buf.String()
+				return result, nil
+			}
+			trace.addContextItem(ContextItemTrace{Kind: ItemEntity, ID: e.NodeID(), Title: e.Name, Tokens: tokens})
+		}
+	}
+
+	if len(units) > 0 && header("-----Sources-----\nid|text\n") {
+		for i, unit := range units {
+			tokens, ok := add(fmt.Sprintf("%d|%s\n", i+1, unit.Text))
+			if !ok {
+				result.Text = buf.String()
+				return result, nil
+			}
+			trace.addContextItem(ContextItemTrace{Kind: ItemTextUnit, ID: unit.ID, Tokens: tokens})
+		}
+	}
+
+	result.Text = buf.String()
+	return result, nil
+}
+
+func (cb *ContextBuilder) tokenCounter() (TokenCounter, error) {
+	if cb.TokenCounter != nil {
+		return cb.TokenCounter, nil
+	}
+	return TiktokenCounter()
+}
+
+// dedupeReports removes duplicate reports, keeping the first occurrence of each.
+func (cb *ContextBuilder) dedupeReports(reports []*model.CommunityReport) []*model.CommunityReport {
+	if cb.Deduplicator == nil {
+		return reports
+	}
+	return cb.Deduplicator.Reports(reports)
+}
+
+// reportBatches renders reports into context tables, starting a new batch whenever the next
+// report would exceed maxTokens. A report larger than maxTokens gets a batch of its own.
+func (cb *ContextBuilder) reportBatches(countTokens TokenCounter, reports []*model.CommunityReport, maxTokens int, trace *Trace) []string {
+	header := "-----Reports-----\nid|title|content\n"
+	headerTokens := countTokens(header)
+
+	batches := make([]string, 0)
+	buf := new(strings.Builder)
+	usedTokens := 0
+
+	for _, report := range reports {
+		row := fmt.Sprintf("%s|%s|%s\n", reportRef(report), report.Title, reportContent(report))
+		tokens := countTokens(row)
+
+		if buf.Len() > 0 && usedTokens+tokens > maxTokens {
+			batches = append(batches, buf.String())
+			buf.Reset()
+		}
+
+		if buf.Len() == 0 {
+			buf.WriteString(header)
+			usedTokens = headerTokens
+		}
+
+		buf.WriteString(row)
+		usedTokens += tokens
+
+		trace.addContextItem(ContextItemTrace{
+			Kind:   ItemReport,
+			ID:     report.ID,
+			Title:  report.Title,
+			Tokens: tokens,
+			Batch:  len(batches),
+		})
+	}
+
+	if buf.Len() > 0 {
+		batches = append(batches, buf.String())
+	}
+
+	return batches
+}
+
+func reportRef(report *model.CommunityReport) string {
+	if report.ShortID != "" {
+		return report.ShortID
+	}
+	return report.ID
+}
@@ -0,0 +1,70 @@
+package query_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ivanvanderbyl/graphrag-go/pkg/extractors/entity"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/model"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/query"
+	"github.com/stretchr/testify/require"
+)
+
+// countWords is a token counter for tests, which avoids downloading a tiktoken encoding
+func countWords(text string) int {
+	return len(strings.Fields(text))
+}
+
+func TestContextBuilderBuild(t *testing.T) {
+	r := require.New(t)
+
+	entities := []*entity.Entity{
+		{Name: "EPA", Description: "An independent regulator."},
+		{Name: "epa", Description: "An  independent regulator."},
+	}
+	units := []*model.TextUnit{
+		{Identified: model.Identified{ID: "unit-1"}, Text: "The EPA will enforce environmental standards."},
+		{Identified: model.Identified{ID: "unit-2"}, Text: "Higgins is an electorate in Victoria."},
+	}
+
+	result, err := query.NewContextBuilder(query.WithTokenCounter(countWords)).Build(entities, units)
+	r.NoError(err)
+	r.Nil(result.Trace)
+	r.Contains(result.Text, "1|EPA|An independent regulator.")
+	r.NotContains(result.Text, "epa")
+	r.Contains(result.Text, "2|Higgins is an electorate in Victoria.")
+
+	result, err = query.NewContextBuilder(query.WithTokenCounter(countWords)).Build(entities, units, query.WithDebug())
+	r.NoError(err)
+	r.NotNil(result.Trace)
+
+	// The duplicate entity is dropped before the budget is applied
+	r.Len(result.Trace.Context, 3)
+	r.Equal(query.ItemEntity, result.Trace.Context[0].Kind)
+	r.Equal("EPA", result.Trace.Context[0].Title)
+	r.Positive(result.Trace.Context[0].Tokens)
+	r.Equal(query.ItemTextUnit, result.Trace.Context[1].Kind)
+	r.Equal("unit-1", result.Trace.Context[1].ID)
+	r.Equal("unit-2", result.Trace.Context[2].ID)
+}
+
+func TestContextBuilderBuildBudget(t *testing.T) {
+	r := require.New(t)
+
+	units := []*model.TextUnit{
+		{Identified: model.Identified{ID: "unit-1"}, Text: "The EPA will enforce environmental standards."},
+		{Identified: model.Identified{ID: "unit-2"}, Text: "Higgins is an electorate in Victoria."},
+	}
+
+	// Room for the header and the first unit only
+	cb := query.NewContextBuilder(query.WithMaxContextTokens(8), query.WithTokenCounter(countWords))
+
+	result, err := cb.Build(nil, units, query.WithDebug())
+	r.NoError(err)
+	r.NotContains(result.Text, "Entities")
+	r.NotContains(result.Text, "Higgins")
+
+	// Only the items that fit are traced
+	r.Len(result.Trace.Context, 1)
+	r.Equal("unit-1", result.Trace.Context[0].ID)
+}
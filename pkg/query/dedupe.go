@@ -0,0 +1,211 @@
+package query
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/ivanvanderbyl/graphrag-go/pkg/extractors/entity"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/model"
+)
+
+// Cosine similarity above which two embeddings are considered to describe the same content
+const DefaultSimilarityThreshold = 0.95
+
+var ErrInvalidThreshold = fmt.Errorf("similarity threshold must be greater than 0 and at most 1")
+
+type (
+	// Deduplicator collapses text units, entity descriptions and reports that repeat the same content, so
+	// that query context isn't spent on the same facts more than once. Content is considered
+	// duplicate when its normalised text hashes to the same value, or when the cosine similarity
+	// of its embeddings reaches the similarity threshold. Content without an embedding is only
+	// compared by hash. The zero value uses DefaultSimilarityThreshold; use NewDeduplicator to set
+	// another threshold.
+	Deduplicator struct {
+		threshold float64
+	}
+
+	DedupeOption func(*Deduplicator)
+)
+
+func NewDeduplicator(opts ...DedupeOption) (*Deduplicator, error) {
+	d := &Deduplicator{
+		threshold: DefaultSimilarityThreshold,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	if d.threshold <= 0 || d.threshold > 1 {
+		return nil, ErrInvalidThreshold
+	}
+
+	return d, nil
+}
+
+// WithDedupeThreshold sets the similarity threshold
+func WithDedupeThreshold(threshold float64) DedupeOption {
+	return func(d *Deduplicator) {
+		d.threshold = threshold
+	}
+}
+
+// SimilarityThreshold returns the cosine similarity at which embeddings are considered duplicates
+func (d *Deduplicator) SimilarityThreshold() float64 {
+	if d.threshold == 0 {
+		return DefaultSimilarityThreshold
+	}
+	return d.threshold
+}
+
+// TextUnits returns units with duplicates removed, preserving the order of first occurrence. The
+// document, entity and relationship IDs of a collapsed unit are merged into the unit that is kept.
+// The input units are not modified.
+func (d *Deduplicator) TextUnits(units []*model.TextUnit) []*model.TextUnit {
+	result := make([]*model.TextUnit, 0, len(units))
+	seen := make(map[[sha1.Size]byte]int)
+
+	for _, unit := range units {
+		if unit == nil {
+			continue
+		}
+
+		key := contentHash(unit.Text)
+		idx, ok := seen[key]
+		if !ok {
+			idx = slices.IndexFunc(result, func(kept *model.TextUnit) bool {
+				return similar(kept.TextEmbedding, unit.TextEmbedding, d.SimilarityThreshold())
+			})
+		}
+
+		if idx < 0 {
+			kept := *unit
+			seen[key] = len(result)
+			result = append(result, &kept)
+			continue
+		}
+
+		seen[key] = idx
+		kept := result[idx]
+		kept.DocumentIDs = mergeIDs(kept.DocumentIDs, unit.DocumentIDs)
+		kept.EntityIDs = mergeIDs(kept.EntityIDs, unit.EntityIDs)
+		kept.RelationshipIDs = mergeIDs(kept.RelationshipIDs, unit.RelationshipIDs)
+	}
+
+	return result
+}
+
+// Entities returns entities with overlapping descriptions removed, preserving the order of first
+// occurrence. Only entities sharing the same name are compared, as different entities may
+// legitimately share similar descriptions.
+func (d *Deduplicator) Entities(entities []*entity.Entity) []*entity.Entity {
+	result := make([]*entity.Entity, 0, len(entities))
+	seen := make(map[[sha1.Size]byte]bool)
+
+	for _, e := range entities {
+		if e == nil {
+			continue
+		}
+
+		name := normalizeText(e.Name)
+		key := contentHash(name + "\x00" + e.Description)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		duplicate := slices.ContainsFunc(result, func(kept *entity.Entity) bool {
+			return normalizeText(kept.Name) == name && similar(kept.Embedding, e.Embedding, d.SimilarityThreshold())
+		})
+		if duplicate {
+			continue
+		}
+
+		result = append(result, e)
+	}
+
+	return result
+}
+
+// Reports returns reports with duplicates removed, preserving the order of first occurrence.
+// Reports are compared by their full content, falling back to their summary.
+func (d *Deduplicator) Reports(reports []*model.CommunityReport) []*model.CommunityReport {
+	result := make([]*model.CommunityReport, 0, len(reports))
+	seen := make(map[[sha1.Size]byte]bool)
+
+	for _, report := range reports {
+		if report == nil {
+			continue
+		}
+
+		key := contentHash(reportContent(report))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		duplicate := slices.ContainsFunc(result, func(kept *model.CommunityReport) bool {
+			if len(kept.FullContentEmbedding) > 0 && len(report.FullContentEmbedding) > 0 {
+				return similar(kept.FullContentEmbedding, report.FullContentEmbedding, d.SimilarityThreshold())
+			}
+			return similar(kept.SummaryEmbedding, report.SummaryEmbedding, d.SimilarityThreshold())
+		})
+		if duplicate {
+			continue
+		}
+
+		result = append(result, report)
+	}
+
+	return result
+}
+
+// similar reports whether two embeddings reach threshold. Missing or mismatched embeddings never
+// match.
+func similar[T float32 | float64](a, b []T, threshold float64) bool {
+	if len(a) == 0 || len(a) != len(b) {
+		return false
+	}
+	return cosineSimilarity(a, b) >= threshold
+}
+
+func cosineSimilarity[T float32 | float64](a, b []T) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+func normalizeText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+func contentHash(text string) [sha1.Size]byte {
+	return sha1.Sum([]byte(normalizeText(text)))
+}
+
+func mergeIDs(ids, others []string) []string {
+	for _, id := range others {
+		if !slices.Contains(ids, id) {
+			ids = append(slices.Clip(ids), id)
+		}
+	}
+	return ids
+}
+
+func reportContent(report *model.CommunityReport) string {
+	if report.FullContent != "" {
+		return report.FullContent
+	}
+	return report.Summary
+}
@@ -0,0 +1,142 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/ivanvanderbyl/graphrag-go/pkg/extractors/entity"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/model"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/query"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeduplicateTextUnits(t *testing.T) {
+	a := assert.New(t)
+
+	units := []*model.TextUnit{
+		{
+			Identified:    model.Identified{ID: "1"},
+			Text:          "The EPA will enforce environmental standards.",
+			TextEmbedding: []float64{1, 0, 0},
+			DocumentIDs:   []string{"doc-1"},
+		},
+		{
+			Identified:    model.Identified{ID: "2"},
+			Text:          "the EPA  will enforce\nenvironmental standards.",
+			TextEmbedding: []float64{0, 1, 0},
+			DocumentIDs:   []string{"doc-2"},
+		},
+		{
+			Identified:    model.Identified{ID: "3"},
+			Text:          "Environmental standards will be enforced by the EPA.",
+			TextEmbedding: []float64{0.99, 0.01, 0},
+			DocumentIDs:   []string{"doc-3"},
+		},
+		{
+			Identified:    model.Identified{ID: "4"},
+			Text:          "Higgins is an electorate in Victoria.",
+			TextEmbedding: []float64{0, 0, 1},
+			DocumentIDs:   []string{"doc-1"},
+		},
+	}
+
+	d, err := query.NewDeduplicator()
+	a.NoError(err)
+	result := d.TextUnits(units)
+
+	a.Len(result, 2)
+	a.Equal("1", result[0].ID)
+	a.Equal([]string{"doc-1", "doc-2", "doc-3"}, result[0].DocumentIDs)
+	a.Equal("4", result[1].ID)
+
+	// Input units are left untouched
+	a.Equal([]string{"doc-1"}, units[0].DocumentIDs)
+}
+
+func TestDeduplicateEntities(t *testing.T) {
+	a := assert.New(t)
+
+	entities := []*entity.Entity{
+		{Name: "EPA", Description: "An independent regulator.", Embedding: []float32{1, 0}},
+		{Name: "epa", Description: "An  independent regulator.", Embedding: []float32{0, 1}},
+		{Name: "EPA", Description: "A regulator that is independent.", Embedding: []float32{0.98, 0.02}},
+		{Name: "EPA", Description: "Established by the Nature Positive Bill.", Embedding: []float32{0, 1}},
+		{Name: "WWF", Description: "An independent regulator.", Embedding: []float32{1, 0}},
+	}
+
+	d, err := query.NewDeduplicator()
+	a.NoError(err)
+	result := d.Entities(entities)
+
+	a.Len(result, 3)
+	a.Equal(entities[0], result[0])
+	a.Equal(entities[3], result[1])
+	a.Equal(entities[4], result[2])
+}
+
+func TestDeduplicateThreshold(t *testing.T) {
+	a := assert.New(t)
+
+	units := []*model.TextUnit{
+		{Text: "first", TextEmbedding: []float64{1, 0}},
+		{Text: "second", TextEmbedding: []float64{0.8, 0.6}},
+	}
+
+	d, err := query.NewDeduplicator()
+	a.NoError(err)
+	a.Len(d.TextUnits(units), 2)
+
+	d, err = query.NewDeduplicator(query.WithDedupeThreshold(0.75))
+	a.NoError(err)
+	a.Len(d.TextUnits(units), 1)
+
+	_, err = query.NewDeduplicator(query.WithDedupeThreshold(0))
+	a.ErrorIs(err, query.ErrInvalidThreshold)
+	_, err = query.NewDeduplicator(query.WithDedupeThreshold(1.5))
+	a.ErrorIs(err, query.ErrInvalidThreshold)
+}
+
+func TestDeduplicateWithoutEmbeddings(t *testing.T) {
+	a := assert.New(t)
+
+	units := []*model.TextUnit{
+		{Text: "first"},
+		{Text: "second", TextEmbedding: []float64{1, 0}},
+		{Text: "third"},
+	}
+
+	// Units without embeddings are only compared by hash
+	a.Len((&query.Deduplicator{}).TextUnits(units), 3)
+}
+
+func TestDeduplicatorZeroValue(t *testing.T) {
+	a := assert.New(t)
+
+	units := []*model.TextUnit{
+		{Text: "first", TextEmbedding: []float64{1, 0}},
+		{Text: "second", TextEmbedding: []float64{0.99, 0.01}},
+	}
+
+	// The zero value still compares embeddings, using the default threshold
+	d := &query.Deduplicator{}
+	a.Equal(query.DefaultSimilarityThreshold, d.SimilarityThreshold())
+	a.Len(d.TextUnits(units), 1)
+}
+
+func TestDeduplicateReports(t *testing.T) {
+	a := assert.New(t)
+
+	reports := []*model.CommunityReport{
+		{Identified: model.Identified{ID: "1"}, FullContent: "The EPA is a regulator.", FullContentEmbedding: []float64{1, 0}},
+		{Identified: model.Identified{ID: "2"}, FullContent: "the EPA is a  regulator."},
+		{Identified: model.Identified{ID: "3"}, FullContent: "A regulator, the EPA.", FullContentEmbedding: []float64{0.99, 0.01}},
+		{Identified: model.Identified{ID: "4"}, Summary: "Higgins is an electorate."},
+	}
+
+	d, err := query.NewDeduplicator()
+	a.NoError(err)
+	result := d.Reports(reports)
+
+	a.Len(result, 2)
+	a.Equal("1", result[0].ID)
+	a.Equal("4", result[1].ID)
+}
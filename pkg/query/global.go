@@ -0,0 +1,314 @@
+package query
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ivanvanderbyl/graphrag-go/pkg/llm"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/model"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/prompts"
+)
+
+// Max number of reports shortlisted by rank for a global search
+const DefaultMaxReports = 50
+
+// Answer returned when no analyst found anything relevant to the query
+const NoDataAnswer = "I am sorry but I am unable to answer this question given the provided data."
+
+type (
+	// GlobalSearch answers a query by asking the LLM for key points from batches of community
+	// reports (the map phase), then combining the most important points into a single answer (the
+	// reduce phase).
+	GlobalSearch struct {
+		mapLLM         llm.LLM
+		reduceLLM      llm.LLM
+		ContextBuilder *ContextBuilder
+		MaxTokens      int // Max context tokens of each map and reduce prompt
+		MaxReports     int
+		MinRank        float64
+		ResponseType   string
+	}
+
+	GlobalSearchOption func(*GlobalSearch)
+
+	SearchOptions struct {
+		Debug bool // Return the full retrieval trace with the result
+	}
+
+	SearchOption func(*SearchOptions)
+
+	Result struct {
+		Answer string
+		Trace  *Trace // Only set when searching with WithDebug
+	}
+
+	KeyPoint struct {
+		Description string `json:"description"`
+		Score       int    `json:"score"`
+	}
+
+	MapData struct {
+		prompts.PromptData
+		Query       string
+		ContextData string
+	}
+
+	ReduceData struct {
+		prompts.PromptData
+		Query        string
+		ReportData   string
+		ResponseType string
+	}
+)
+
+func NewGlobalSearch(l llm.LLM, opts ...GlobalSearchOption) *GlobalSearch {
+	gs := &GlobalSearch{
+		mapLLM:         l,
+		reduceLLM:      l,
+		ContextBuilder: NewContextBuilder(),
+		MaxTokens:      DefaultMaxContextTokens,
+		MaxReports:     DefaultMaxReports,
+		ResponseType:   "multiple paragraphs",
+	}
+	for _, opt := range opts {
+		opt(gs)
+	}
+	if gs.ContextBuilder == nil {
+		gs.ContextBuilder = NewContextBuilder()
+	}
+	return gs
+}
+
+// WithStageModels runs the map and reduce phases with the models assigned to each stage
+func WithStageModels(models llm.StageModels) GlobalSearchOption {
+	return func(gs *GlobalSearch) {
+		gs.mapLLM = models.ForStage(gs.mapLLM, llm.StageQueryMap)
+		gs.reduceLLM = models.ForStage(gs.reduceLLM, llm.StageQueryReduce)
+	}
+}
+
+// WithMaxReports sets the max number of reports shortlisted, or all reports when zero
+func WithMaxReports(maxReports int) GlobalSearchOption {
+	return func(gs *GlobalSearch) {
+		gs.MaxReports = maxReports
+	}
+}
+
+// WithMinRank sets the min rank of reports shortlisted
+func WithMinRank(minRank float64) GlobalSearchOption {
+	return func(gs *GlobalSearch) {
+		gs.MinRank = minRank
+	}
+}
+
+// WithGlobalContextTokens sets the max context tokens of each map and reduce prompt
+func WithGlobalContextTokens(maxTokens int) GlobalSearchOption {
+	return func(gs *GlobalSearch) {
+		gs.MaxTokens = maxTokens
+	}
+}
+
+// WithContextBuilder sets the context builder used to deduplicate and batch reports, or the
+// default builder when nil. The MaxTokens of the builder is not used; see WithGlobalContextTokens.
+func WithContextBuilder(cb *ContextBuilder) GlobalSearchOption {
+	return func(gs *GlobalSearch) {
+		gs.ContextBuilder = cb
+	}
+}
+
+// WithResponseType sets the target length and format of the answer
+func WithResponseType(responseType string) GlobalSearchOption {
+	return func(gs *GlobalSearch) {
+		gs.ResponseType = responseType
+	}
+}
+
+// WithDebug returns the retrieval trace with the result
+func WithDebug() SearchOption {
+	return func(o *SearchOptions) {
+		o.Debug = true
+	}
+}
+
+// Search answers query from the highest ranked reports. With WithDebug, a failed search still
+// returns a result holding the trace gathered up to the failure.
+func (gs *GlobalSearch) Search(ctx context.Context, query string, reports []*model.CommunityReport, opts ...SearchOption) (*Result, error) {
+	options := &SearchOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	var trace *Trace
+	if options.Debug {
+		trace = &Trace{}
+	}
+
+	countTokens, err := gs.ContextBuilder.tokenCounter()
+	if err != nil {
+		return nil, err
+	}
+
+	batches := gs.ContextBuilder.reportBatches(countTokens, gs.shortlist(reports, trace), gs.MaxTokens, trace)
+
+	points := make([]KeyPoint, 0)
+	for i, batch := range batches {
+		batchPoints, err := gs.mapBatch(ctx, query, i, batch, trace)
+		if err != nil {
+			return partialResult(trace), err
+		}
+		points = append(points, batchPoints...)
+	}
+
+	answer, err := gs.reduce(ctx, countTokens, query, points, trace)
+	if err != nil {
+		return partialResult(trace), err
+	}
+
+	return &Result{
+		Answer: answer,
+		Trace:  trace,
+	}, nil
+}
+
+// partialResult returns the trace gathered before a failure, so failed searches can be debugged.
+// Without a trace there is nothing to return.
+func partialResult(trace *Trace) *Result {
+	if trace == nil {
+		return nil
+	}
+	return &Result{Trace: trace}
+}
+
+// shortlist returns reports meeting MinRank, highest rank first, limited to MaxReports. Duplicate
+// reports are removed before the limit is applied, keeping the highest ranked copy.
+func (gs *GlobalSearch) shortlist(reports []*model.CommunityReport, trace *Trace) []*model.CommunityReport {
+	sorted := slices.Clone(reports)
+	slices.SortStableFunc(sorted, func(a, b *model.CommunityReport) int {
+		return cmp.Compare(b.Rank, a.Rank)
+	})
+
+	unique := gs.ContextBuilder.dedupeReports(sorted)
+
+	selected := make([]*model.CommunityReport, 0, len(unique))
+	for _, report := range sorted {
+		ok := report.Rank >= gs.MinRank &&
+			(gs.MaxReports <= 0 || len(selected) < gs.MaxReports) &&
+			slices.Contains(unique, report)
+		if ok {
+			selected = append(selected, report)
+		}
+
+		trace.addCandidate(CandidateTrace{
+			ID:       report.ID,
+			Title:    report.Title,
+			Score:    report.Rank,
+			Selected: ok,
+		})
+	}
+
+	return selected
+}
+
+func (gs *GlobalSearch) mapBatch(ctx context.Context, query string, batch int, contextData string, trace *Trace) ([]KeyPoint, error) {
+	prompt, err := prompts.RenderTemplate(prompts.GlobalMapTemplate, MapData{
+		PromptData:  prompts.DefaultPromptData,
+		Query:       query,
+		ContextData: contextData,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := gs.mapLLM.Generate(ctx, prompt)
+	if err != nil {
+		trace.addPrompt(PromptTrace{Phase: PhaseMap, Batch: batch, Prompt: prompt, Error: err.Error()})
+		return nil, err
+	}
+
+	points := processKeyPoints(resp)
+
+	trace.addPrompt(PromptTrace{Phase: PhaseMap, Batch: batch, Prompt: prompt, Response: resp})
+	trace.addMapOutput(MapOutputTrace{Batch: batch, Points: points})
+
+	return points, nil
+}
+
+func (gs *GlobalSearch) reduce(ctx context.Context, countTokens TokenCounter, query string, points []KeyPoint, trace *Trace) (string, error) {
+	points = slices.DeleteFunc(points, func(p KeyPoint) bool {
+		return p.Score <= 0
+	})
+	if len(points) == 0 {
+		return NoDataAnswer, nil
+	}
+
+	slices.SortStableFunc(points, func(a, b KeyPoint) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+
+	buf := new(strings.Builder)
+	usedTokens := 0
+	for i, point := range points {
+		text := fmt.Sprintf("----Analyst %d----\nImportance Score: %d\n%s\n\n", i+1, point.Score, point.Description)
+		tokens := countTokens(text)
+		if buf.Len() > 0 && usedTokens+tokens > gs.MaxTokens {
+			break
+		}
+		usedTokens += tokens
+		buf.WriteString(text)
+	}
+
+	prompt, err := prompts.RenderTemplate(prompts.GlobalReduceTemplate, ReduceData{
+		PromptData:   prompts.DefaultPromptData,
+		Query:        query,
+		ReportData:   buf.String(),
+		ResponseType: gs.ResponseType,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := gs.reduceLLM.Generate(ctx, prompt)
+	if err != nil {
+		trace.addPrompt(PromptTrace{Phase: PhaseReduce, Prompt: prompt, Error: err.Error()})
+		return "", err
+	}
+
+	trace.addPrompt(PromptTrace{Phase: PhaseReduce, Prompt: prompt, Response: resp})
+
+	return resp, nil
+}
+
+func processKeyPoints(response string) []KeyPoint {
+	before, ok := strings.CutSuffix(strings.TrimSpace(response), prompts.DefaultCompletionDelimiter)
+	if ok {
+		response = before
+	}
+
+	points := make([]KeyPoint, 0)
+	for _, part := range strings.Split(response, prompts.DefaultRecordDelimiter) {
+		record := strings.TrimSpace(part)
+		record = strings.TrimPrefix(record, "(")
+		record = strings.TrimSuffix(record, ")")
+
+		attrs := strings.SplitN(record, prompts.DefaultTupleDelimiter, 3)
+		if len(attrs) < 3 || prompts.CleanString(attrs[0]) != "point" {
+			continue
+		}
+
+		score, err := strconv.Atoi(prompts.CleanString(attrs[1]))
+		if err != nil {
+			continue
+		}
+
+		points = append(points, KeyPoint{
+			Score:       score,
+			Description: prompts.CleanString(attrs[2]),
+		})
+	}
+
+	return points
+}
@@ -0,0 +1,191 @@
+package query_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ivanvanderbyl/graphrag-go/pkg/llm"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/model"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/query"
+	"github.com/stretchr/testify/require"
+)
+
+type stubLLM struct {
+	prompts []string
+}
+
+func (s *stubLLM) Generate(ctx context.Context, prompt string, opts ...llm.Option) (string, error) {
+	s.prompts = append(s.prompts, prompt)
+	if strings.Contains(prompt, "-Analyst Reports-") {
+		return "The EPA will enforce environmental standards.", nil
+	}
+	if strings.Contains(prompt, "Environment Protection Authority") {
+		return `("point"<|>80<|>"The EPA enforces environmental standards [Data: Reports (1)]")<|COMPLETE|>`, nil
+	}
+	return `("point"<|>0<|>"I don't know.")<|COMPLETE|>`, nil
+}
+
+type failingLLM struct {
+	stubLLM
+	err error
+}
+
+func (f *failingLLM) Generate(ctx context.Context, prompt string, opts ...llm.Option) (string, error) {
+	if strings.Contains(prompt, "-Analyst Reports-") {
+		return "", f.err
+	}
+	return f.stubLLM.Generate(ctx, prompt, opts...)
+}
+
+func (s *stubLLM) Embedding(ctx context.Context, input string, opts ...llm.Option) ([]float32, error) {
+	return nil, nil
+}
+
+// newTestSearch returns a global search which counts words as tokens
+func newTestSearch(l llm.LLM, opts ...query.GlobalSearchOption) *query.GlobalSearch {
+	cb := query.NewContextBuilder(query.WithTokenCounter(countWords))
+	return query.NewGlobalSearch(l, append([]query.GlobalSearchOption{query.WithContextBuilder(cb)}, opts...)...)
+}
+
+var testReports = []*model.CommunityReport{
+	{
+		Identified:  model.Identified{ID: "report-1", ShortID: "1"},
+		Title:       "Environment Protection Authority",
+		FullContent: "The Environment Protection Authority is an independent regulator.",
+		Rank:        8,
+	},
+	{
+		Identified:  model.Identified{ID: "report-2", ShortID: "2"},
+		Title:       "Riverside Book Club",
+		FullContent: "The Riverside Book Club meets monthly.",
+		Rank:        2,
+	},
+	{
+		Identified:  model.Identified{ID: "report-3", ShortID: "3"},
+		Title:       "Higgins",
+		FullContent: "Higgins is an electorate in Victoria.",
+		Rank:        5,
+	},
+}
+
+func TestGlobalSearch(t *testing.T) {
+	r := require.New(t)
+
+	stub := &stubLLM{}
+	search := newTestSearch(stub, query.WithMaxReports(2), query.WithGlobalContextTokens(12))
+
+	result, err := search.Search(context.TODO(), "Who enforces environmental standards?", testReports)
+	r.NoError(err)
+	r.Equal("The EPA will enforce environmental standards.", result.Answer)
+	r.Nil(result.Trace)
+
+	// Two map batches and one reduce
+	r.Len(stub.prompts, 3)
+}
+
+func TestGlobalSearchDebugTrace(t *testing.T) {
+	r := require.New(t)
+
+	search := newTestSearch(&stubLLM{}, query.WithMaxReports(2), query.WithGlobalContextTokens(12))
+
+	result, err := search.Search(context.TODO(), "Who enforces environmental standards?", testReports, query.WithDebug())
+	r.NoError(err)
+	r.NotNil(result.Trace)
+
+	trace := result.Trace
+	r.Len(trace.Candidates, 3)
+	r.Equal("report-1", trace.Candidates[0].ID)
+	r.Equal(8.0, trace.Candidates[0].Score)
+	r.True(trace.Candidates[0].Selected)
+	r.True(trace.Candidates[1].Selected)
+	r.False(trace.Candidates[2].Selected)
+
+	r.Len(trace.Context, 2)
+	r.Equal("report-1", trace.Context[0].ID)
+	r.Equal(0, trace.Context[0].Batch)
+	r.Equal(1, trace.Context[1].Batch)
+	r.Positive(trace.Context[0].Tokens)
+
+	r.Len(trace.MapOutputs, 2)
+	r.Equal(80, trace.MapOutputs[0].Points[0].Score)
+	r.Equal(0, trace.MapOutputs[1].Points[0].Score)
+
+	r.Len(trace.Prompts, 3)
+	r.Equal(query.PhaseMap, trace.Prompts[0].Phase)
+	r.Contains(trace.Prompts[0].Prompt, "Environment Protection Authority")
+	r.Equal(query.PhaseReduce, trace.Prompts[2].Phase)
+	r.Contains(trace.Prompts[2].Prompt, "Importance Score: 80")
+	r.Equal(result.Answer, trace.Prompts[2].Response)
+}
+
+func TestGlobalSearchDeduplicatesReports(t *testing.T) {
+	r := require.New(t)
+
+	duplicate := *testReports[0]
+	duplicate.ID = "report-1-copy"
+	duplicate.Rank = 7
+	reports := append([]*model.CommunityReport{&duplicate}, testReports...)
+
+	search := newTestSearch(&stubLLM{}, query.WithMaxReports(2))
+	result, err := search.Search(context.TODO(), "Who enforces environmental standards?", reports, query.WithDebug())
+	r.NoError(err)
+
+	r.Len(result.Trace.Candidates, 4)
+	r.Equal("report-1-copy", result.Trace.Candidates[1].ID)
+	r.False(result.Trace.Candidates[1].Selected)
+
+	// The duplicate doesn't take a shortlist slot from the next report
+	r.Len(result.Trace.Context, 2)
+	r.Equal("report-3", result.Trace.Context[1].ID)
+}
+
+func TestGlobalSearchNoData(t *testing.T) {
+	r := require.New(t)
+
+	stub := &stubLLM{}
+	search := newTestSearch(stub, query.WithMinRank(3))
+
+	result, err := search.Search(context.TODO(), "What does the book club discuss?", testReports[1:])
+	r.NoError(err)
+	r.Equal(query.NoDataAnswer, result.Answer)
+	r.Len(stub.prompts, 1)
+}
+
+func TestGlobalSearchPartialTrace(t *testing.T) {
+	r := require.New(t)
+
+	stub := &failingLLM{err: errors.New("rate limited")}
+	search := newTestSearch(stub, query.WithMaxReports(2), query.WithGlobalContextTokens(12))
+
+	result, err := search.Search(context.TODO(), "Who enforces environmental standards?", testReports)
+	r.ErrorIs(err, stub.err)
+	r.Nil(result)
+
+	result, err = search.Search(context.TODO(), "Who enforces environmental standards?", testReports, query.WithDebug())
+	r.ErrorIs(err, stub.err)
+	r.NotNil(result)
+	r.Empty(result.Answer)
+
+	// Map outputs are kept, and the failed reduce prompt is recorded with its error
+	trace := result.Trace
+	r.Len(trace.MapOutputs, 2)
+	r.Len(trace.Prompts, 3)
+	r.Equal(query.PhaseReduce, trace.Prompts[2].Phase)
+	r.Contains(trace.Prompts[2].Prompt, "Importance Score: 80")
+	r.Equal("rate limited", trace.Prompts[2].Error)
+}
+
+func TestGlobalSearchContextBuilder(t *testing.T) {
+	r := require.New(t)
+
+	// The token budget belongs to the search, and doesn't modify a shared builder
+	cb := query.NewContextBuilder(query.WithTokenCounter(countWords))
+	search := query.NewGlobalSearch(&stubLLM{}, query.WithGlobalContextTokens(12), query.WithContextBuilder(cb))
+	r.Equal(12, search.MaxTokens)
+	r.Equal(query.DefaultMaxContextTokens, cb.MaxTokens)
+
+	search = query.NewGlobalSearch(&stubLLM{}, query.WithContextBuilder(nil))
+	r.NotNil(search.ContextBuilder)
+}
@@ -0,0 +1,81 @@
+package query
+
+// Phases of a query that send prompts to the LLM
+const (
+	PhaseMap    = "map"
+	PhaseReduce = "reduce"
+)
+
+// Kinds of item included in query context
+const (
+	ItemEntity   = "entity"
+	ItemTextUnit = "text_unit"
+	ItemReport   = "report"
+)
+
+type (
+	// Trace records how a query answer was produced: which items were considered, which made it
+	// into the context, and every prompt sent along with its response.
+	Trace struct {
+		Candidates []CandidateTrace   `json:"candidates"`
+		Context    []ContextItemTrace `json:"context"`
+		Prompts    []PromptTrace      `json:"prompts"`
+		MapOutputs []MapOutputTrace   `json:"map_outputs,omitempty"`
+	}
+
+	// CandidateTrace is an item considered for the context, and whether it was shortlisted.
+	CandidateTrace struct {
+		ID       string  `json:"id"`
+		Title    string  `json:"title"`
+		Score    float64 `json:"score"`
+		Selected bool    `json:"selected"`
+	}
+
+	// ContextItemTrace is an item included in the context of a prompt.
+	ContextItemTrace struct {
+		Kind   string `json:"kind"`
+		ID     string `json:"id"`
+		Title  string `json:"title,omitempty"`
+		Tokens int    `json:"tokens"`
+		Batch  int    `json:"batch"`
+	}
+
+	PromptTrace struct {
+		Phase    string `json:"phase"`
+		Batch    int    `json:"batch"`
+		Prompt   string `json:"prompt"`
+		Response string `json:"response"`
+		Error    string `json:"error,omitempty"`
+	}
+
+	MapOutputTrace struct {
+		Batch  int        `json:"batch"`
+		Points []KeyPoint `json:"points"`
+	}
+)
+
+// The add methods are no-ops on a nil Trace, so queries only pay for tracing when debugging.
+
+func (t *Trace) addCandidate(c CandidateTrace) {
+	if t != nil {
+		t.Candidates = append(t.Candidates, c)
+	}
+}
+
+func (t *Trace) addContextItem(item ContextItemTrace) {
+	if t != nil {
+		t.Context = append(t.Context, item)
+	}
+}
+
+func (t *Trace) addPrompt(p PromptTrace) {
+	if t != nil {
+		t.Prompts = append(t.Prompts, p)
+	}
+}
+
+func (t *Trace) addMapOutput(o MapOutputTrace) {
+	if t != nil {
+		t.MapOutputs = append(t.MapOutputs, o)
+	}
+}
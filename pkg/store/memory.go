@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"iter"
+
+	"github.com/ivanvanderbyl/graphrag-go/pkg/extractors/entity"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/model"
+)
+
+type (
+	// MemoryStore serves records already held in memory, such as the results of an extractor.
+	MemoryStore struct {
+		entities      []*entity.Entity
+		relationships []*entity.Relationship
+		textUnits     []*model.TextUnit
+		reports       []*model.CommunityReport
+	}
+
+	MemoryOption func(*MemoryStore)
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore(opts ...MemoryOption) *MemoryStore {
+	s := &MemoryStore{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithRecords adds the entities and relationships returned by an extractor
+func WithRecords(records []entity.Record) MemoryOption {
+	return func(s *MemoryStore) {
+		for _, record := range records {
+			switch r := record.(type) {
+			case *entity.Entity:
+				s.entities = append(s.entities, r)
+			case *entity.Relationship:
+				s.relationships = append(s.relationships, r)
+			}
+		}
+	}
+}
+
+// WithTextUnits adds text units
+func WithTextUnits(units []*model.TextUnit) MemoryOption {
+	return func(s *MemoryStore) {
+		s.textUnits = append(s.textUnits, units...)
+	}
+}
+
+// WithReports adds community reports
+func WithReports(reports []*model.CommunityReport) MemoryOption {
+	return func(s *MemoryStore) {
+		s.reports = append(s.reports, reports...)
+	}
+}
+
+func (s *MemoryStore) Entities(ctx context.Context) iter.Seq2[*entity.Entity, error] {
+	return sliceSeq(ctx, s.entities)
+}
+
+func (s *MemoryStore) Relationships(ctx context.Context) iter.Seq2[*entity.Relationship, error] {
+	return sliceSeq(ctx, s.relationships)
+}
+
+func (s *MemoryStore) TextUnits(ctx context.Context) iter.Seq2[*model.TextUnit, error] {
+	return sliceSeq(ctx, s.textUnits)
+}
+
+func (s *MemoryStore) Reports(ctx context.Context) iter.Seq2[*model.CommunityReport, error] {
+	return sliceSeq(ctx, s.reports)
+}
+
+// sliceSeq yields each item of a slice, stopping with an error once the context is done
+func sliceSeq[T any](ctx context.Context, items []T) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for _, item := range items {
+			if err := ctx.Err(); err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
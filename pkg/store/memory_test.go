@@ -0,0 +1,66 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ivanvanderbyl/graphrag-go/pkg/extractors/entity"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/model"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	r := require.New(t)
+
+	records := []entity.Record{
+		entity.NewEntity("1", "organization", "EPA", "An independent regulator."),
+		entity.NewRelationship("2", "EPA", "Nature Positive Bill", "The EPA is established by the bill.", 8),
+		entity.NewEntity("3", "bill", "Nature Positive Bill", "A bill establishing the EPA."),
+	}
+	s := store.NewMemoryStore(
+		store.WithRecords(records),
+		store.WithTextUnits([]*model.TextUnit{{Identified: model.Identified{ID: "unit-1"}}}),
+	)
+
+	entities, err := store.Collect(s.Entities(context.TODO()))
+	r.NoError(err)
+	r.Len(entities, 2)
+	r.Equal("EPA", entities[0].Name)
+	r.Equal("bill", entities[1].Type())
+
+	relationships, err := store.Collect(s.Relationships(context.TODO()))
+	r.NoError(err)
+	r.Len(relationships, 1)
+	r.Equal("Nature Positive Bill", relationships[0].Entity2)
+
+	units, err := store.Collect(s.TextUnits(context.TODO()))
+	r.NoError(err)
+	r.Len(units, 1)
+
+	reports, err := store.Collect(s.Reports(context.TODO()))
+	r.NoError(err)
+	r.Empty(reports)
+
+	// Breaking out of the loop stops iteration
+	count := 0
+	for range s.Entities(context.TODO()) {
+		count++
+		break
+	}
+	r.Equal(1, count)
+}
+
+func TestMemoryStoreCancelled(t *testing.T) {
+	r := require.New(t)
+
+	s := store.NewMemoryStore(store.WithRecords([]entity.Record{
+		entity.NewEntity("1", "organization", "EPA", "An independent regulator."),
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := store.Collect(s.Entities(ctx))
+	r.ErrorIs(err, context.Canceled)
+}
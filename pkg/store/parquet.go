@@ -0,0 +1,218 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	goparquet "github.com/fraugster/parquet-go"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/extractors/entity"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/model"
+)
+
+// File names of the final tables written by a GraphRAG indexing run
+const (
+	EntitiesFile      = "create_final_entities.parquet"
+	RelationshipsFile = "create_final_relationships.parquet"
+	TextUnitsFile     = "create_final_text_units.parquet"
+	ReportsFile       = "create_final_community_reports.parquet"
+)
+
+type (
+	// ParquetStore reads the parquet output of a GraphRAG indexing run from a directory, one row
+	// at a time.
+	ParquetStore struct {
+		Dir string
+	}
+
+	row map[string]interface{}
+)
+
+var _ Store = (*ParquetStore)(nil)
+
+func NewParquetStore(dir string) *ParquetStore {
+	return &ParquetStore{
+		Dir: dir,
+	}
+}
+
+func (s *ParquetStore) Entities(ctx context.Context) iter.Seq2[*entity.Entity, error] {
+	return readRows(ctx, filepath.Join(s.Dir, EntitiesFile), decodeEntity)
+}
+
+func (s *ParquetStore) Relationships(ctx context.Context) iter.Seq2[*entity.Relationship, error] {
+	return readRows(ctx, filepath.Join(s.Dir, RelationshipsFile), decodeRelationship)
+}
+
+func (s *ParquetStore) TextUnits(ctx context.Context) iter.Seq2[*model.TextUnit, error] {
+	return readRows(ctx, filepath.Join(s.Dir, TextUnitsFile), decodeTextUnit)
+}
+
+func (s *ParquetStore) Reports(ctx context.Context) iter.Seq2[*model.CommunityReport, error] {
+	return readRows(ctx, filepath.Join(s.Dir, ReportsFile), decodeReport)
+}
+
+// readRows opens a parquet file when iteration starts, and decodes each row as it is read
+func readRows[T any](ctx context.Context, path string, decode func(row) T) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		f, err := os.Open(path)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer f.Close()
+
+		fr, err := goparquet.NewFileReader(f)
+		if err != nil {
+			yield(zero, fmt.Errorf("failed to read %s: %w", path, err))
+			return
+		}
+
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			r, err := fr.NextRow()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(zero, fmt.Errorf("failed to read %s: %w", path, err))
+				return
+			}
+
+			if !yield(decode(r), nil) {
+				return
+			}
+		}
+	}
+}
+
+func decodeEntity(r row) *entity.Entity {
+	e := entity.NewEntity(toString(r["id"]), toString(r["type"]), toString(r["name"]), toString(r["description"]))
+	for _, v := range toFloats(r["description_embedding"]) {
+		e.Embedding = append(e.Embedding, float32(v))
+	}
+	return e
+}
+
+func decodeRelationship(r row) *entity.Relationship {
+	return entity.NewRelationship(
+		toString(r["id"]),
+		toString(r["source"]),
+		toString(r["target"]),
+		toString(r["description"]),
+		int(math.Round(toFloat(r["weight"]))),
+	)
+}
+
+func decodeTextUnit(r row) *model.TextUnit {
+	return &model.TextUnit{
+		Identified:      model.Identified{ID: toString(r["id"]), ShortID: toString(r["human_readable_id"])},
+		Text:            toString(r["text"]),
+		NTokens:         int(toFloat(r["n_tokens"])),
+		DocumentIDs:     toStrings(r["document_ids"]),
+		EntityIDs:       toStrings(r["entity_ids"]),
+		RelationshipIDs: toStrings(r["relationship_ids"]),
+	}
+}
+
+func decodeReport(r row) *model.CommunityReport {
+	return &model.CommunityReport{
+		Identified:      model.Identified{ID: toString(r["id"]), ShortID: toString(r["community"])},
+		Title:           toString(r["title"]),
+		CommunityID:     toString(r["community"]),
+		Summary:         toString(r["summary"]),
+		FullContent:     toString(r["full_content"]),
+		Rank:            toFloat(r["rank"]),
+		RankExplanation: toString(r["rank_explanation"]),
+	}
+}
+
+// toString converts a column value to a string, formatting numbers such as community IDs
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+func toStrings(v interface{}) []string {
+	values := listValues(v)
+	if len(values) == 0 {
+		return nil
+	}
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		result = append(result, toString(value))
+	}
+	return result
+}
+
+func toFloats(v interface{}) []float64 {
+	values := listValues(v)
+	if len(values) == 0 {
+		return nil
+	}
+	result := make([]float64, 0, len(values))
+	for _, value := range values {
+		result = append(result, toFloat(value))
+	}
+	return result
+}
+
+func toFloat(v interface{}) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case []byte:
+		f, _ := strconv.ParseFloat(string(v), 64)
+		return f
+	default:
+		return 0
+	}
+}
+
+// listValues flattens a list column. Lists written by pandas are nested as
+// {"list": [{"element": v}, ...]}, while plain repeated columns are read as a slice.
+func listValues(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		return listValues(v["list"])
+	case []map[string]interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			values = append(values, item["element"])
+		}
+		return values
+	default:
+		return nil
+	}
+}
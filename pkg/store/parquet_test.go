@@ -0,0 +1,67 @@
+package store
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeRows(t *testing.T) {
+	a := assert.New(t)
+
+	e := decodeEntity(row{
+		"id":                    []byte("entity-1"),
+		"name":                  []byte("EPA"),
+		"type":                  []byte("organization"),
+		"description":           []byte("An independent regulator."),
+		"description_embedding": map[string]interface{}{"list": []map[string]interface{}{{"element": 0.5}, {"element": 0.25}}},
+	})
+	a.Equal("entity-1", e.NodeID())
+	a.Equal("organization", e.Type())
+	a.Equal("EPA", e.Name)
+	a.Equal([]float32{0.5, 0.25}, e.Embedding)
+
+	rel := decodeRelationship(row{
+		"id":          []byte("rel-1"),
+		"source":      []byte("EPA"),
+		"target":      []byte("Nature Positive Bill"),
+		"description": []byte("The EPA is established by the bill."),
+		"weight":      7.6,
+	})
+	a.Equal("EPA", rel.Entity1)
+	a.Equal("Nature Positive Bill", rel.Entity2)
+	a.Equal(8, rel.Weight)
+
+	unit := decodeTextUnit(row{
+		"id":           []byte("unit-1"),
+		"text":         []byte("The EPA will enforce environmental standards."),
+		"n_tokens":     int64(7),
+		"document_ids": []interface{}{[]byte("doc-1"), []byte("doc-2")},
+	})
+	a.Equal("unit-1", unit.ID)
+	a.Equal(7, unit.NTokens)
+	a.Equal([]string{"doc-1", "doc-2"}, unit.DocumentIDs)
+	a.Nil(unit.EntityIDs)
+
+	report := decodeReport(row{
+		"id":           []byte("report-1"),
+		"community":    int64(12),
+		"title":        []byte("Environment Protection Authority"),
+		"full_content": []byte("The EPA is an independent regulator."),
+		"rank":         8.5,
+	})
+	a.Equal("12", report.CommunityID)
+	a.Equal("12", report.ShortID)
+	a.Equal(8.5, report.Rank)
+}
+
+func TestParquetStoreMissingFile(t *testing.T) {
+	a := assert.New(t)
+
+	// The file is opened when iteration starts, and the error is yielded
+	entities, err := Collect(NewParquetStore(t.TempDir()).Entities(context.TODO()))
+	a.ErrorIs(err, os.ErrNotExist)
+	a.Nil(entities)
+}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"context"
+	"iter"
+
+	"github.com/ivanvanderbyl/graphrag-go/pkg/extractors/entity"
+	"github.com/ivanvanderbyl/graphrag-go/pkg/model"
+)
+
+type (
+	// Store streams the contents of an index, so large indexes can be processed without loading
+	// every record into memory. Each iterator yields records paired with a nil error, and stops
+	// after yielding the first error with a zero record.
+	Store interface {
+		Entities(ctx context.Context) iter.Seq2[*entity.Entity, error]
+		Relationships(ctx context.Context) iter.Seq2[*entity.Relationship, error]
+		TextUnits(ctx context.Context) iter.Seq2[*model.TextUnit, error]
+		Reports(ctx context.Context) iter.Seq2[*model.CommunityReport, error]
+	}
+)
+
+// Collect reads every record from an iterator into a slice, for APIs that take slices such as
+// query.GlobalSearch.
+func Collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	items := make([]T, 0)
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}